@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/thomaspoignant/api-scenario/pkg/importer"
+	"github.com/thomaspoignant/api-scenario/pkg/util"
+)
+
+var generateOutputDir string
+var openapiGroupBy string
+
+// init setup the flags used by the generate command.
+func init() {
+	rootCmd.AddCommand(generateCmd)
+	generateCmd.PersistentFlags().StringVarP(&generateOutputDir, "output-dir", "o", ".", "Directory where to save the scenarios.")
+	generateCmd.AddCommand(generateOpenapiCmd)
+	generateOpenapiCmd.Flags().StringVarP(&openapiGroupBy, "group-by", "g", string(importer.GroupByTag), "Create one scenario per tag or per path (tag|path).")
+}
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate skeleton scenarios from an API specification",
+	Long:  `Generate skeleton scenarios from an API specification`,
+}
+
+var generateOpenapiCmd = &cobra.Command{
+	Use:   "openapi <spec.yaml>",
+	Short: "Generate scenarios from an OpenAPI 3 specification",
+	Long: `Generate scenarios from an OpenAPI 3 specification (YAML or JSON).
+Every operation is converted into a request step with an example body and assertions on the documented success codes.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		groupBy := importer.GroupBy(openapiGroupBy)
+		if groupBy != importer.GroupByTag && groupBy != importer.GroupByPath {
+			util.ExitIfErr(fmt.Errorf("invalid value for --group-by: %s (expected tag or path)", openapiGroupBy))
+		}
+
+		spec, err := ioutil.ReadFile(args[0])
+		util.ExitIfErr(err)
+
+		scenarios, warnings, err := importer.GenerateFromOpenAPI(spec, groupBy)
+		util.ExitIfErr(err)
+
+		for _, warning := range warnings {
+			logrus.Warnf("! %s", warning)
+		}
+		saveImportedScenarios(scenarios, generateOutputDir)
+	},
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ghodss/yaml"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/thomaspoignant/api-scenario/pkg/importer"
+	"github.com/thomaspoignant/api-scenario/pkg/util"
+)
+
+var importOutputDir string
+var postmanEnvironmentFile string
+
+// init setup the flags used by the import command.
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.PersistentFlags().StringVarP(&importOutputDir, "output-dir", "o", ".", "Directory where to save the scenarios.")
+	importCmd.AddCommand(importPostmanCmd)
+	importPostmanCmd.Flags().StringVarP(&postmanEnvironmentFile, "environment", "e", "", "Postman environment file with the values of the variables.")
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import scenarios from other tools",
+	Long:  `Import scenarios from other tools`,
+}
+
+var importPostmanCmd = &cobra.Command{
+	Use:   "postman <collection.json>",
+	Short: "Import a Postman collection (v2.1)",
+	Long:  `Import a Postman collection (v2.1), every folder of the collection is converted into a scenario file.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		collection, err := ioutil.ReadFile(args[0])
+		util.ExitIfErr(err)
+
+		var environment []byte
+		if len(postmanEnvironmentFile) > 0 {
+			environment, err = ioutil.ReadFile(postmanEnvironmentFile)
+			util.ExitIfErr(err)
+		}
+
+		scenarios, warnings, err := importer.ImportPostman(collection, environment)
+		util.ExitIfErr(err)
+
+		for _, warning := range warnings {
+			logrus.Warnf("! %s", warning)
+		}
+		saveImportedScenarios(scenarios, importOutputDir)
+	},
+}
+
+// saveImportedScenarios is writing the scenarios in YAML files in the output directory.
+func saveImportedScenarios(scenarios []importer.ImportedScenario, outputDir string) {
+	for _, imported := range scenarios {
+		content, err := yaml.Marshal(imported.Scenario)
+		util.ExitIfErr(err)
+
+		file := filepath.Join(outputDir, imported.Path)
+		err = os.MkdirAll(filepath.Dir(file), 0755)
+		util.ExitIfErr(err)
+		err = ioutil.WriteFile(file, content, 0644)
+		util.ExitIfErr(err)
+		logrus.Infof("Scenario %s saved", file)
+	}
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/ghodss/yaml"
 	"io/ioutil"
 	"os"
@@ -13,25 +14,52 @@ import (
 	"github.com/thomaspoignant/api-scenario/pkg/context"
 	"github.com/thomaspoignant/api-scenario/pkg/controller"
 	"github.com/thomaspoignant/api-scenario/pkg/model"
+	"github.com/thomaspoignant/api-scenario/pkg/report"
+	"github.com/thomaspoignant/api-scenario/pkg/secret"
 	"github.com/thomaspoignant/api-scenario/pkg/util"
 )
 
 var headers []string
 var variables []string
-var inputFile string
+var envFiles []string
+var secrets []string
+var secretPatterns []string
+var inputFiles []string
 var token string
 var outputFile string
 var outputFormat string
+var parallel int
+var timeout int
+var oauth2Config model.OAuth2
+var oauth2GrantType string
+var tlsConfig model.TLSConfig
 
 // init setup the flags used by the run command.
 func init() {
 	rootCmd.AddCommand(runCmd)
-	runCmd.Flags().StringVarP(&inputFile, "scenario", "s", "", "Input file for the scenario.")
+	runCmd.Flags().StringArrayVarP(&inputFiles, "scenario", "s", []string{}, "Input file, glob pattern or directory for the scenarios (can be used multiple times).")
 	runCmd.Flags().StringArrayVarP(&headers, "header", "H", []string{}, "Header you want to override (format should be \"header_name:value\").")
 	runCmd.Flags().StringArrayVarP(&variables, "variable", "V", []string{}, "Value for a variable used in your scenario (format should be \"variable_name:value\").")
+	runCmd.Flags().StringArrayVarP(&envFiles, "env", "e", []string{}, "Environment file (YAML, JSON or dotenv) with variables and headers, the next files override the previous ones (can be used multiple times).")
+	runCmd.Flags().StringArrayVar(&secrets, "secret", []string{}, "Name of a variable to mask in the console output and in the output file (can be used multiple times).")
+	runCmd.Flags().StringArrayVar(&secretPatterns, "secret-pattern", []string{}, "Regex matching the names of the variables to mask in the console output and in the output file (can be used multiple times).")
 	runCmd.Flags().StringVarP(&token, "authorization-token", "t", "", "Authorization token send in the Authorization headers.")
-	runCmd.Flags().StringVarP(&outputFile, "output-file", "f", "", "Output file where to save the result (use --output-format to specify if you want JSON or YAML output).")
-	runCmd.Flags().StringVar(&outputFormat, "output-format", "JSON", "Format of the output file, available values are JSON and YAML (ignored if --output-file is not set).")
+	runCmd.Flags().StringVar(&oauth2Config.TokenURL, "oauth2-token-url", "", "Token URL of the OAuth2 server, the requests are authenticated with an OAuth2 access token.")
+	runCmd.Flags().StringVar(&oauth2GrantType, "oauth2-grant-type", model.ClientCredentialsGrant.String(), "OAuth2 grant used to get the access token, available values are client_credentials and password.")
+	runCmd.Flags().StringVar(&oauth2Config.ClientID, "oauth2-client-id", "", "OAuth2 client ID.")
+	runCmd.Flags().StringVar(&oauth2Config.ClientSecret, "oauth2-client-secret", "", "OAuth2 client secret.")
+	runCmd.Flags().StringVar(&oauth2Config.Username, "oauth2-username", "", "Username of the OAuth2 password grant.")
+	runCmd.Flags().StringVar(&oauth2Config.Password, "oauth2-password", "", "Password of the OAuth2 password grant.")
+	runCmd.Flags().StringArrayVar(&oauth2Config.Scopes, "oauth2-scope", []string{}, "Scope requested for the OAuth2 access token (can be used multiple times).")
+	runCmd.Flags().StringVar(&tlsConfig.CACert, "ca-cert", "", "PEM file with the certificates of the authorities to trust in addition to the system ones.")
+	runCmd.Flags().StringVar(&tlsConfig.ClientCert, "cert", "", "PEM file of the client certificate used for mutual TLS (use --key for its private key).")
+	runCmd.Flags().StringVar(&tlsConfig.ClientKey, "key", "", "PEM file of the private key of the client certificate.")
+	runCmd.Flags().StringVar(&tlsConfig.ServerName, "server-name", "", "Name used to verify the certificate of the server instead of the host of the URL.")
+	runCmd.Flags().BoolVar(&tlsConfig.Insecure, "insecure", false, "Do not verify the certificate of the server.")
+	runCmd.Flags().StringVarP(&outputFile, "output-file", "f", "", "Output file where to save the result (use --output-format to specify if you want JSON, YAML, JUNIT or HTML output).")
+	runCmd.Flags().IntVar(&timeout, "timeout", 30, "Default timeout in seconds for a request, 0 means no timeout.")
+	runCmd.Flags().IntVarP(&parallel, "parallel", "p", 1, "Number of scenarios to run in parallel.")
+	runCmd.Flags().StringVar(&outputFormat, "output-format", "JSON", "Format of the output file, available values are JSON, YAML, JUNIT and HTML (ignored if --output-file is not set).")
 	if err := runCmd.MarkFlagRequired("scenario"); err != nil {
 		panic(err)
 	}
@@ -42,23 +70,65 @@ var runCmd = &cobra.Command{
 	Short: "Execute your scenario",
 	Long:  `Execute your scenario`,
 	Run: func(cmd *cobra.Command, args []string) {
+		// the environment files are loaded first, the command line can override them
+		env, err := model.LoadEnvironmentFiles(envFiles)
+		util.ExitIfErr(err)
+
+		// the secrets are declared before adding the variables, so their values are registered
+		util.ExitIfErr(registerSecrets(append(env.Secrets, secrets...), secretPatterns, token))
+		for key, value := range env.Variables {
+			context.GetContext().Add(key, value)
+		}
+
 		// add variable to context
 		addVariableToContext(variables)
 
 		// format headers and add it to the config
-		viper.Set("headers", formatHeadersForConfig(headers, token))
+		configHeaders := env.Headers
+		for key, value := range formatHeadersForConfig(headers, token) {
+			configHeaders[key] = value
+		}
+		viper.Set("headers", configHeaders)
+		viper.Set("timeout", timeout)
 
-		// Parse the input file
-		scenario, err := model.InitScenarioFromFile(inputFile)
+		// the authentication of the command line is used by the scenarios without auth
+		auth, err := oauth2Auth(oauth2Config, oauth2GrantType)
 		util.ExitIfErr(err)
+		context.GetContext().SetAuth(auth)
 
-		// run the scenario
-		ctrl, err := controller.InitializeScenarioController()
+		// the TLS settings of the command line can be overridden by the scenarios
+		tls, err := tlsSettings(tlsConfig)
 		util.ExitIfErr(err)
+		context.GetContext().SetTLS(tls)
 
-		res := ctrl.Run(scenario)
-		saveResultInFile(res)
-		if !res.IsSuccess() {
+		// Find and parse the input files
+		files, err := model.ListScenarioFiles(inputFiles)
+		util.ExitIfErr(err)
+
+		var scenarios []model.Scenario
+		for _, file := range files {
+			scenario, err := model.InitScenarioFromFile(file)
+			util.ExitIfErr(err)
+			scenarios = append(scenarios, scenario)
+		}
+		if !checkRequiredVariables(files, scenarios, context.GetContext()) {
+			os.Exit(1)
+		}
+
+		// run the scenarios
+		ctrl, err := controller.InitializeSuiteController()
+		util.ExitIfErr(err)
+
+		suite := ctrl.Run(scenarios, context.GetContext(), parallel)
+		for index := range suite.ScenarioResults {
+			suite.ScenarioResults[index].File = files[index]
+		}
+
+		if len(suite.ScenarioResults) > 1 {
+			suite.PrintSummary()
+		}
+		saveResultInFile(suite)
+		if !suite.IsSuccess() {
 			os.Exit(1)
 		}
 	},
@@ -77,6 +147,61 @@ func addVariableToContext(variables []string) {
 	}
 }
 
+// registerSecrets declares the secret variables and the authorization token to mask them in the outputs.
+func registerSecrets(names []string, patterns []string, token string) error {
+	registry := secret.GetRegistry()
+	for _, name := range names {
+		registry.AddName(name)
+	}
+	for _, pattern := range patterns {
+		if err := registry.AddNamePattern(pattern); err != nil {
+			return err
+		}
+	}
+	registry.AddValue(token)
+	return nil
+}
+
+// oauth2Auth creates the OAuth2 authentication from the options, it returns nil if there is no token URL.
+func oauth2Auth(config model.OAuth2, grantType string) (*model.Auth, error) {
+	if len(config.TokenURL) == 0 {
+		return nil, nil
+	}
+	var err error
+	if config.GrantType, err = model.OAuth2GrantTypeString(grantType); err != nil {
+		return nil, err
+	}
+	if len(config.ClientID) == 0 {
+		return nil, fmt.Errorf("the option --oauth2-client-id is needed with --oauth2-token-url")
+	}
+	return &model.Auth{Type: model.AuthOAuth2, OAuth2: &config}, nil
+}
+
+// tlsSettings checks the TLS options, it returns nil if no option is set.
+func tlsSettings(config model.TLSConfig) (*model.TLSConfig, error) {
+	if config == (model.TLSConfig{}) {
+		return nil, nil
+	}
+	if (len(config.ClientCert) == 0) != (len(config.ClientKey) == 0) {
+		return nil, fmt.Errorf("the options --cert and --key should be used together")
+	}
+	return &config, nil
+}
+
+// checkRequiredVariables checks that the required variables of the scenarios are in the context.
+func checkRequiredVariables(files []string, scenarios []model.Scenario, ctx *context.Context) bool {
+	success := true
+	for index, scenario := range scenarios {
+		for _, name := range scenario.RequiredVariables {
+			if _, ok := ctx.Get(name); !ok {
+				logrus.Errorf("X	%s: the required variable {{%s}} is not defined", files[index], name)
+				success = false
+			}
+		}
+	}
+	return success
+}
+
 // formatHeadersForConfig is formatting headers to put them in the config
 func formatHeadersForConfig(headers []string, token string) map[string]string {
 	const separator = ":"
@@ -98,18 +223,32 @@ func formatHeadersForConfig(headers []string, token string) map[string]string {
 }
 
 // save result in a file
-func saveResultInFile(result model.ScenarioResult) {
+func saveResultInFile(suite model.SuiteResult) {
 
 	if len(outputFile) == 0 {
 		return
 	}
 
+	// The secrets are never saved in the output file.
+	suite = suite.Masked()
+
+	// When there is only one scenario we keep the scenario format in JSON and YAML.
+	var result interface{} = suite
+	if len(suite.ScenarioResults) == 1 {
+		result = suite.ScenarioResults[0]
+	}
+
 	var s []byte
 	var err error
 	// Marshal in the choosing format
-	if strings.ToUpper(outputFormat) == "YAML" || strings.ToUpper(outputFormat) == "YML" {
+	switch strings.ToUpper(outputFormat) {
+	case "YAML", "YML":
 		s, err = yaml.Marshal(result)
-	} else {
+	case "JUNIT":
+		s, err = report.JUnit(suite.ScenarioResults)
+	case "HTML":
+		s, err = report.HTML(suite.ScenarioResults)
+	default:
 		s, err = json.Marshal(result)
 	}
 	util.ExitIfErr(err)
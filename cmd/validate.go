@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/thomaspoignant/api-scenario/pkg/controller"
+	"github.com/thomaspoignant/api-scenario/pkg/log"
+	"github.com/thomaspoignant/api-scenario/pkg/model"
+	"github.com/thomaspoignant/api-scenario/pkg/util"
+)
+
+var validateFiles []string
+var validateVariables []string
+var validateEnvFiles []string
+
+// init setup the flags used by the validate command.
+func init() {
+	rootCmd.AddCommand(validateCmd)
+	validateCmd.Flags().StringArrayVarP(&validateFiles, "scenario", "s", []string{}, "Input file, glob pattern or directory for the scenarios (can be used multiple times).")
+	validateCmd.Flags().StringArrayVarP(&validateVariables, "variable", "V", []string{}, "Variable available for your scenario (format should be \"variable_name:value\" or \"variable_name\").")
+	validateCmd.Flags().StringArrayVarP(&validateEnvFiles, "env", "e", []string{}, "Environment file (YAML, JSON or dotenv) with the variables available for your scenario (can be used multiple times).")
+	if err := validateCmd.MarkFlagRequired("scenario"); err != nil {
+		panic(err)
+	}
+}
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check your scenario files without executing them",
+	Long:  `Check your scenario files without executing them`,
+	Run: func(cmd *cobra.Command, args []string) {
+		files, err := model.ListScenarioFiles(validateFiles)
+		util.ExitIfErr(err)
+
+		env, err := model.LoadEnvironmentFiles(validateEnvFiles)
+		util.ExitIfErr(err)
+
+		var knownVariables []string
+		for variable := range env.Variables {
+			knownVariables = append(knownVariables, variable)
+		}
+		for _, variable := range validateVariables {
+			knownVariables = append(knownVariables, strings.TrimSpace(strings.SplitN(variable, ":", 2)[0]))
+		}
+
+		hasError := false
+		for _, file := range files {
+			scenario, err := model.InitScenarioFromFile(file)
+			if err != nil {
+				hasError = true
+				logrus.Errorf("X\t%v", err)
+				continue
+			}
+
+			errs := controller.ValidateScenario(file, scenario, knownVariables)
+			if len(errs) == 0 {
+				logrus.Infof(log.SuccessColor.Sprint("✓\t")+"%s", file)
+				continue
+			}
+
+			hasError = true
+			for _, validationErr := range errs {
+				logrus.Errorf("X\t%s", validationErr.Error())
+			}
+		}
+
+		if hasError {
+			os.Exit(1)
+		}
+	},
+}
@@ -4,6 +4,9 @@ go 1.14
 
 require (
 	github.com/alvaroloes/enumer v1.1.2 // indirect
+	github.com/andybalholm/cascadia v1.2.0
+	github.com/antchfx/xmlquery v1.3.10
+	github.com/antchfx/xpath v1.3.2
 	github.com/clbanning/mxj v1.8.4
 	github.com/fatih/color v1.9.0
 	github.com/fsnotify/fsnotify v1.4.9 // indirect
@@ -24,7 +27,10 @@ require (
 	github.com/spf13/jwalterweatherman v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
 	github.com/spf13/viper v1.7.0
+	github.com/spyzhov/ajson v0.8.0
 	github.com/thanhpk/randstr v1.0.4
+	github.com/xeipuuv/gojsonschema v1.2.0
+	golang.org/x/net v0.0.0-20200813134508-3edf25e44fcc
 	golang.org/x/sys v0.0.0-20200515095857-1151b9dac4a9 // indirect
 	gopkg.in/ini.v1 v1.56.0 // indirect
 )
@@ -9,8 +9,10 @@ import (
 	"encoding/hex"
 	"fmt"
 	hash2 "hash"
+	"io/ioutil"
 	"math/rand"
 	"net/url"
+	"os"
 	"regexp"
 	"strconv"
 	"strings"
@@ -19,8 +21,50 @@ import (
 	"github.com/google/uuid"
 	"github.com/metakeule/fmtdate"
 	"github.com/thanhpk/randstr"
+	"github.com/thomaspoignant/api-scenario/pkg/secret"
 )
 
+// envVariable replace {{env(NAME)}} by the value of the environment variable NAME of the OS.
+// If the environment variable is not set the placeholder is kept and an error is returned.
+// The value of a secret environment variable is registered to be masked in the outputs.
+func envVariable(s string) (string, error) {
+	r := regexp.MustCompile(`{{env\(\s*([A-Za-z_][A-Za-z0-9_]*)\s*\)}}`)
+	var err error
+	for _, subMatch := range r.FindAllStringSubmatch(s, -1) {
+		variable := subMatch[0]
+		name := subMatch[1]
+
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			err = fmt.Errorf("the environment variable %s is not set", name)
+			continue
+		}
+		secret.GetRegistry().AddVariable(name, value)
+		s = strings.Replace(s, variable, value, 1)
+	}
+	return s, err
+}
+
+// fileContent replace {{file(path)}} by the content of the file, without the newlines at the end of the file.
+// The path is relative to the working directory. If the file can not be read the placeholder is kept
+// and an error is returned.
+func fileContent(s string) (string, error) {
+	r := regexp.MustCompile(`{{file\(([^()]+)\)}}`)
+	var err error
+	for _, subMatch := range r.FindAllStringSubmatch(s, -1) {
+		variable := subMatch[0]
+		path := strings.TrimSpace(subMatch[1])
+
+		content, readErr := ioutil.ReadFile(path)
+		if readErr != nil {
+			err = fmt.Errorf("impossible to read the file %s: %v", path, readErr)
+			continue
+		}
+		s = strings.Replace(s, variable, strings.TrimRight(string(content), "\r\n"), 1)
+	}
+	return s, err
+}
+
 // randomInt replace {{random_int}} by a Random integer between 0 and 18446744073709551615
 func randomInt(s string) string {
 	if key := "{{random_int}}"; strings.Contains(s, key) {
@@ -1,53 +1,192 @@
 package context
 
 import (
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
 	"strings"
 	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/thomaspoignant/api-scenario/pkg/model"
+	"github.com/thomaspoignant/api-scenario/pkg/secret"
 )
 
-// context is a key value store where we keep all the variable name and replacement string.
-type context struct {
+// Context is a key value store where we keep all the variable name and replacement string.
+// It also holds the logger used to display the execution of the scenario, the cookies received during the scenario,
+// the authentication and the TLS configuration of the requests.
+type Context struct {
+	mu        sync.RWMutex
 	variables map[string]string
+	logger    *logrus.Logger
+	cookies   http.CookieJar
+	auth      *model.Auth
+	tls       *model.TLSConfig
 }
 
-var instance *context
+var instance *Context
 var once sync.Once
 
 // GetContext allows to get a singleton of the context.
-func GetContext() *context {
+func GetContext() *Context {
 	once.Do(func() {
-		instance = &context{
-			variables: make(map[string]string),
-		}
+		instance = NewContext()
 	})
 	return instance
 }
 
+// NewContext creates an empty context using the standard logger.
+func NewContext() *Context {
+	return &Context{
+		variables: make(map[string]string),
+		logger:    logrus.StandardLogger(),
+	}
+}
+
+// Clone creates a new context with a copy of the variables, the authentication and the TLS configuration
+// of the current context and an empty cookie jar.
+func (context *Context) Clone() *Context {
+	context.mu.RLock()
+	defer context.mu.RUnlock()
+
+	variables := make(map[string]string, len(context.variables))
+	for key, value := range context.variables {
+		variables[key] = value
+	}
+	return &Context{
+		variables: variables,
+		logger:    context.logger,
+		auth:      context.auth,
+		tls:       context.tls,
+	}
+}
+
 // Add a new variable to the context.
-func (context *context) Add(key string, value string) {
+// The value of a secret variable is registered to be masked in the outputs.
+func (context *Context) Add(key string, value string) {
+	secret.GetRegistry().AddVariable(key, value)
+	context.mu.Lock()
+	defer context.mu.Unlock()
 	context.variables[key] = value
 }
 
-// ResetContext remove all the variable in the context.
-func (context *context) ResetContext() {
+// Get returns the value of a variable and if the variable exists in the context.
+func (context *Context) Get(key string) (string, bool) {
+	context.mu.RLock()
+	defer context.mu.RUnlock()
+	value, ok := context.variables[key]
+	return value, ok
+}
+
+// ResetContext remove all the variable, the cookies, the authentication and the TLS configuration in the context.
+func (context *Context) ResetContext() {
+	context.mu.Lock()
+	defer context.mu.Unlock()
 	context.variables = map[string]string{}
+	context.cookies = nil
+	context.auth = nil
+	context.tls = nil
+}
+
+// CookieJar returns the cookie jar of the context, it is created at the first call.
+func (context *Context) CookieJar() http.CookieJar {
+	context.mu.Lock()
+	defer context.mu.Unlock()
+	if context.cookies == nil {
+		// cookiejar.New never returns an error without options
+		context.cookies, _ = cookiejar.New(nil)
+	}
+	return context.cookies
+}
+
+// ClearCookies removes all the cookies of the context.
+func (context *Context) ClearCookies() {
+	context.mu.Lock()
+	defer context.mu.Unlock()
+	context.cookies = nil
+}
+
+// Auth returns the authentication of the requests, nil if the requests are not authenticated.
+func (context *Context) Auth() *model.Auth {
+	context.mu.RLock()
+	defer context.mu.RUnlock()
+	return context.auth
+}
+
+// SetAuth changes the authentication of the requests.
+func (context *Context) SetAuth(auth *model.Auth) {
+	context.mu.Lock()
+	defer context.mu.Unlock()
+	context.auth = auth
+}
+
+// TLS returns the TLS configuration of the requests, nil to use the default one.
+func (context *Context) TLS() *model.TLSConfig {
+	context.mu.RLock()
+	defer context.mu.RUnlock()
+	return context.tls
+}
+
+// SetTLS changes the TLS configuration of the requests.
+func (context *Context) SetTLS(tls *model.TLSConfig) {
+	context.mu.Lock()
+	defer context.mu.Unlock()
+	context.tls = tls
+}
+
+// Logger returns the logger to use with this context.
+func (context *Context) Logger() *logrus.Logger {
+	return context.logger
+}
+
+// SetLogger change the logger used with this context.
+func (context *Context) SetLogger(logger *logrus.Logger) {
+	context.logger = logger
 }
 
 // Patch is taking a string and patch all the variable he found in the string
 // a variable is something inside {{variable}}
-func (context *context) Patch(str string) string {
-	result := str
+func (context *Context) Patch(str string) string {
+	result, _ := context.PatchWithError(str)
+	return result
+}
+
+// PatchWithError patches the string as Patch and returns an error if a builtin function can not be applied
+// (e.g. an environment variable or a file not found), in this case the function is kept in the string.
+// The environment variables and the files are read before replacing the variables, so a value extracted
+// from a response can not read them.
+func (context *Context) PatchWithError(str string) (string, error) {
+	result, err := patchExternal(str)
+
+	context.mu.RLock()
 	for key, value := range context.variables {
 		if strings.Contains(result, "{{"+key+"}}") {
 			result = strings.ReplaceAll(result, "{{"+key+"}}", value)
 		}
 	}
-	result = context.patchBuiltin(result)
-	return result
+	context.mu.RUnlock()
+
+	return context.patchBuiltin(result), err
+}
+
+// patchExternal replaces the environment variables and the files, their values can be used by the other functions.
+func patchExternal(s string) (string, error) {
+	var errs []string
+	for _, patch := range []func(string) (string, error){envVariable, fileContent} {
+		var err error
+		if s, err = patch(s); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return s, errors.New(strings.Join(errs, ", "))
+	}
+	return s, nil
 }
 
 // patchBuiltin is applying builtin patches.
-func (context *context) patchBuiltin(s string) string {
+func (context *Context) patchBuiltin(s string) string {
 	s = timestamp(s)
 	s = utcDatetime(s)
 	s = randomInt(s)
@@ -4,7 +4,12 @@ import (
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/thomaspoignant/api-scenario/pkg/context"
+	"github.com/thomaspoignant/api-scenario/pkg/model"
+	"github.com/thomaspoignant/api-scenario/pkg/secret"
 	"github.com/thomaspoignant/api-scenario/test"
+	"net/http"
+	"net/url"
+	"os"
 	"strconv"
 	"strings"
 	"testing"
@@ -289,3 +294,136 @@ func TestBuiltinTimestampOffsetWrongType(t *testing.T) {
 	got := ctx.Patch(input)
 	test.Equals(t, "we should not have patched the value", input, got)
 }
+
+func TestCloneIsIsolated(t *testing.T) {
+	ctx := context.NewContext()
+	ctx.Add("baseUrl", "http://google.fr")
+
+	clone := ctx.Clone()
+	clone.Add("baseUrl", "http://yahoo.fr")
+	clone.Add("name", "John")
+
+	test.Equals(t, "Original context should not be modified", "http://google.fr/{{name}}", ctx.Patch("{{baseUrl}}/{{name}}"))
+	test.Equals(t, "Clone should have its own variables", "http://yahoo.fr/John", clone.Patch("{{baseUrl}}/{{name}}"))
+}
+
+func TestCookieJar(t *testing.T) {
+	ctx := context.NewContext()
+	u, _ := url.Parse("http://test.com")
+	ctx.CookieJar().SetCookies(u, []*http.Cookie{{Name: "session", Value: "abc"}})
+	test.Equals(t, "Should keep the cookies", 1, len(ctx.CookieJar().Cookies(u)))
+	test.Equals(t, "Should not copy the cookies in a clone", 0, len(ctx.Clone().CookieJar().Cookies(u)))
+
+	ctx.ClearCookies()
+	test.Equals(t, "Should remove the cookies", 0, len(ctx.CookieJar().Cookies(u)))
+}
+
+func TestAuth(t *testing.T) {
+	ctx := context.NewContext()
+	auth := &model.Auth{Type: model.AuthOAuth2, OAuth2: &model.OAuth2{ClientID: "app"}}
+	ctx.SetAuth(auth)
+	test.Equals(t, "Should keep the auth in a clone", auth, ctx.Clone().Auth())
+
+	ctx.ResetContext()
+	test.Assert(t, ctx.Auth() == nil, "Should remove the auth")
+}
+
+func TestTLS(t *testing.T) {
+	ctx := context.NewContext()
+	config := &model.TLSConfig{CACert: "ca.pem", Insecure: true}
+	ctx.SetTLS(config)
+	test.Equals(t, "Should keep the TLS configuration in a clone", config, ctx.Clone().TLS())
+
+	ctx.ResetContext()
+	test.Assert(t, ctx.TLS() == nil, "Should remove the TLS configuration")
+}
+
+func TestBuiltinEnv(t *testing.T) {
+	resetContext()
+	ctx := context.GetContext()
+	os.Setenv("API_SCENARIO_TEST_TOKEN", "Token123")
+	defer os.Unsetenv("API_SCENARIO_TEST_TOKEN")
+
+	got, err := ctx.PatchWithError("Bearer {{env(API_SCENARIO_TEST_TOKEN)}}")
+	test.Ok(t, err)
+	test.Equals(t, "should be patched with the environment variable", "Bearer Token123", got)
+}
+
+func TestBuiltinEnvNested(t *testing.T) {
+	resetContext()
+	ctx := context.GetContext()
+	os.Setenv("API_SCENARIO_TEST_TOKEN", "TOTO")
+	defer os.Unsetenv("API_SCENARIO_TEST_TOKEN")
+
+	got := ctx.Patch("{{sha256({{env(API_SCENARIO_TEST_TOKEN)}})}}")
+	want := "f2efb991e19f0edff35aa412b47e49be6f4f694028fe15598951619de915d54a"
+	test.Equals(t, "the environment variable should be usable in a function", want, got)
+}
+
+func TestBuiltinEnvNotSet(t *testing.T) {
+	resetContext()
+	ctx := context.GetContext()
+	input := "Bearer {{env(API_SCENARIO_TEST_UNKNOWN)}}"
+
+	got, err := ctx.PatchWithError(input)
+	test.Ko(t, err)
+	test.Equals(t, "wrong error", "the environment variable API_SCENARIO_TEST_UNKNOWN is not set", err.Error())
+	test.Equals(t, "we should not have patched the value", input, got)
+}
+
+func TestBuiltinFile(t *testing.T) {
+	resetContext()
+	ctx := context.GetContext()
+
+	got, err := ctx.PatchWithError("Bearer {{file(../../testdata/env/token.txt)}}")
+	test.Ok(t, err)
+	test.Equals(t, "should be patched with the content of the file", "Bearer s3cr3t-t0ken", got)
+}
+
+func TestBuiltinFileNotFound(t *testing.T) {
+	resetContext()
+	ctx := context.GetContext()
+	input := "{{file(../../testdata/env/unknown.txt)}}"
+
+	got, err := ctx.PatchWithError(input)
+	test.Ko(t, err)
+	test.Assert(t, strings.HasPrefix(err.Error(), "impossible to read the file ../../testdata/env/unknown.txt:"), "wrong error: %s", err)
+	test.Equals(t, "we should not have patched the value", input, got)
+}
+
+func TestBuiltinEnvNotReadFromVariables(t *testing.T) {
+	ctx := context.NewContext()
+	os.Setenv("API_SCENARIO_TEST_TOKEN", "s3cr3t")
+	defer os.Unsetenv("API_SCENARIO_TEST_TOKEN")
+	ctx.Add("x", "{{env(API_SCENARIO_TEST_TOKEN)}}")
+	ctx.Add("y", "{{file(../../testdata/env/token.txt)}}")
+
+	got, err := ctx.PatchWithError("https://test.com/?q={{x}}&f={{y}}")
+	test.Ok(t, err)
+	test.Equals(t, "the value of a variable should not be expanded",
+		"https://test.com/?q={{env(API_SCENARIO_TEST_TOKEN)}}&f={{file(../../testdata/env/token.txt)}}", got)
+}
+
+func TestAddShortValuesNotMasked(t *testing.T) {
+	defer secret.GetRegistry().Reset()
+	ctx := context.NewContext()
+	ctx.Add("has_token", "true")
+	ctx.Add("tokenCount", "1")
+	ctx.Add("api_token", "yes")
+	ctx.Add("token_id", "20")
+
+	body := `{"id":1201,"active":true,"status":"yes"}`
+	test.Equals(t, "short values should not be masked", body, secret.GetRegistry().Mask(body))
+}
+
+func TestAddSecretVariables(t *testing.T) {
+	defer secret.GetRegistry().Reset()
+	secret.GetRegistry().AddName("session_id")
+	ctx := context.NewContext()
+	ctx.Add("access_token", "extracted-token")
+	ctx.Add("session_id", "42")
+	ctx.Add("api_token", "input-token")
+
+	got := secret.GetRegistry().Mask("extracted-token input-token session=42")
+	test.Equals(t, "the secret variables should be masked", "**** **** session=****", got)
+}
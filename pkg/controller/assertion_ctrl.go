@@ -1,23 +1,33 @@
 package controller
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
 	"github.com/clbanning/mxj"
 	"github.com/google/go-cmp/cmp"
 	"github.com/jmoiron/jsonq"
 	"github.com/thomaspoignant/api-scenario/pkg/context"
 	"github.com/thomaspoignant/api-scenario/pkg/model"
 	"github.com/thomaspoignant/api-scenario/pkg/util"
+	"github.com/xeipuuv/gojsonschema"
+	"golang.org/x/net/html"
+	"io/ioutil"
+	"math"
 	"net/http"
 	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 )
 
 type AssertionController interface {
-	Assert(assertion model.Assertion, resp model.Response) model.ResultAssertion
+	Assert(assertion model.Assertion, resp model.Response, namespaces map[string]string, ctx *context.Context) model.ResultAssertion
 }
 
 type assertionControllerImpl struct {
@@ -29,10 +39,66 @@ func NewAssertionController() AssertionController {
 
 const ComparisonNotSupportedMessage = "the comparison %s was not supported for the source"
 
+// numberComparisons are the comparisons available with assertNumber.
+var numberComparisons = []model.Comparison{
+	model.IsANumber, model.Equal, model.NotEqual, model.EqualNumber, model.IsLessThan,
+	model.IsGreaterThan, model.IsLessThanOrEqual, model.IsGreaterThanOrEqual,
+	model.MatchesRegex, model.DoesNotMatchRegex,
+}
+
+// stringComparisons are the comparisons available with assertString.
+var stringComparisons = []model.Comparison{
+	model.Equal, model.NotEqual, model.Contains, model.DoesNotContain, model.IsANumber, model.EqualNumber,
+	model.IsLessThan, model.IsLessThanOrEqual, model.IsGreaterThan, model.IsGreaterThanOrEqual,
+	model.NotEmpty, model.Empty, model.MatchesRegex, model.DoesNotMatchRegex,
+}
+
+// supportedComparisons are the comparisons available for each source, if a source is not in the map
+// every comparison could be supported depending on the type of the value.
+var supportedComparisons = map[model.Source][]model.Comparison{
+	model.ResponseStatus: numberComparisons,
+	model.ResponseTime:   numberComparisons,
+	model.ResponseText:   stringComparisons,
+	model.ResponseHeader: append([]model.Comparison{model.IsNull, model.HasKey}, stringComparisons...),
+}
+
+// restrictedComparisons are the comparisons available only for some sources.
+var restrictedComparisons = map[model.Comparison][]model.Source{
+	model.MatchesSchema: {model.ResponseJson},
+}
+
+// IsComparisonSupported checks if a comparison can be used with a source.
+func IsComparisonSupported(source model.Source, comparison model.Comparison) bool {
+	if sources, ok := restrictedComparisons[comparison]; ok {
+		for _, allowed := range sources {
+			if allowed == source {
+				return true
+			}
+		}
+		return false
+	}
+
+	comparisons, ok := supportedComparisons[source]
+	if !ok {
+		return true
+	}
+	for _, supported := range comparisons {
+		if supported == comparison {
+			return true
+		}
+	}
+	return false
+}
+
 // Assert is testing an assertion on a API response.
-func (ctrl *assertionControllerImpl) Assert(assertion model.Assertion, resp model.Response) model.ResultAssertion {
+// namespaces are the XML namespaces usable in the XPath expressions of the response_xml assertions.
+func (ctrl *assertionControllerImpl) Assert(assertion model.Assertion, resp model.Response, namespaces map[string]string,
+	ctx *context.Context) model.ResultAssertion {
 
-	assertion = ctrl.patchAssertion(assertion)
+	assertion, err := ctrl.patchAssertion(assertion, ctx)
+	if err != nil {
+		return model.ResultAssertion{Success: false, Message: err.Error(), Err: err, Source: assertion.Source, Property: assertion.Property}
+	}
 
 	switch assertion.Source {
 	case model.ResponseStatus:
@@ -47,12 +113,38 @@ func (ctrl *assertionControllerImpl) Assert(assertion model.Assertion, resp mode
 		return res
 
 	case model.ResponseJson:
-		res := ctrl.assertResponseJson(assertion, resp.Body)
+		var res model.ResultAssertion
+		if assertion.Comparison == model.MatchesSchema {
+			res = ctrl.assertJsonSchema(assertion, resp.Body)
+		} else {
+			res = ctrl.assertResponseJson(assertion, resp.Body)
+		}
 		res.Source = assertion.Source
 		return res
 
 	case model.ResponseXml:
-		res := ctrl.assertResponseXml(assertion, resp.Body)
+		var res model.ResultAssertion
+		if isXPath(assertion.Property) {
+			res = ctrl.assertResponseXPath(assertion, resp.Body, namespaces)
+		} else {
+			res = ctrl.assertResponseXml(assertion, resp.Body)
+		}
+		res.Source = assertion.Source
+		return res
+
+	case model.ResponseHtml:
+		res := ctrl.assertResponseHtml(assertion, resp.Body)
+		res.Source = assertion.Source
+		return res
+
+	case model.ResponseCookie:
+		value, found := cookieValue(resp.Cookies, assertion.Property)
+		res := ctrl.assertExtractedValue(assertion, value, found)
+		res.Source = assertion.Source
+		return res
+
+	case model.ResponseTLS:
+		res := ctrl.assertResponseTLS(assertion, resp.TLS)
 		res.Source = assertion.Source
 		return res
 
@@ -116,12 +208,77 @@ func (ctrl *assertionControllerImpl) assertResponseJson(assertion model.Assertio
 		return model.ResultAssertion{Success: false, Message: message, Err: errors.New(message), Property: assertion.Property}
 	}
 
-	body, err := util.StringToJson(bodyAsString)
+	extractedKey, found, err := util.JsonPathValue(bodyAsString, assertion.Property)
 	if err != nil {
 		return model.ResultAssertion{Success: false, Message: err.Error(), Err: err, Property: assertion.Property}
 	}
 
-	return ctrl.assertResponseMap(assertion, body)
+	// a null value is considered as a missing value
+	return ctrl.assertExtractedValue(assertion, extractedKey, found && extractedKey != nil)
+}
+
+// assertJsonSchema is testing that the JSON body (or one of its properties) matches a JSON schema.
+func (ctrl *assertionControllerImpl) assertJsonSchema(assertion model.Assertion, bodyAsString string) model.ResultAssertion {
+	schema, err := LoadJsonSchema(assertion.Value)
+	if err != nil {
+		return model.ResultAssertion{Success: false, Message: err.Error(), Err: err, Property: assertion.Property}
+	}
+
+	var body interface{}
+	if err := json.Unmarshal([]byte(bodyAsString), &body); err != nil {
+		message := "api Response is not in JSON"
+		return model.ResultAssertion{Success: false, Message: message, Err: errors.New(message), Property: assertion.Property}
+	}
+
+	value := body
+	displayName := "body"
+	if len(assertion.Property) > 0 {
+		displayName = assertion.Property
+		var found bool
+		value, found, err = util.JsonPathValue(bodyAsString, assertion.Property)
+		if err != nil {
+			return model.ResultAssertion{Success: false, Message: err.Error(), Err: err, Property: assertion.Property}
+		}
+		if !found {
+			message := fmt.Sprintf("Unable to locate %s property in path '%s' in JSON", assertion.Property, assertion.Property)
+			return model.ResultAssertion{Success: false, Message: message, Err: errors.New(message), Property: assertion.Property}
+		}
+	}
+
+	validation, err := schema.Validate(gojsonschema.NewGoLoader(value))
+	if err != nil {
+		return model.ResultAssertion{Success: false, Message: err.Error(), Err: err, Property: assertion.Property}
+	}
+	var violations []string
+	for _, violation := range validation.Errors() {
+		violations = append(violations, violation.String())
+	}
+	sort.Strings(violations)
+	var result model.ResultAssertion
+	if len(violations) == 0 {
+		result = model.NewResultAssertion(model.MatchesSchema, true, displayName)
+	} else {
+		result = model.NewResultAssertion(model.MatchesSchema, false, displayName, strings.Join(violations, ", "))
+	}
+	result.Property = assertion.Property
+	return result
+}
+
+// LoadJsonSchema reads the schema of a matches_schema assertion,
+// the value is an inline JSON schema or the path to a schema file.
+func LoadJsonSchema(value string) (*gojsonschema.Schema, error) {
+	content := []byte(value)
+	if !strings.HasPrefix(strings.TrimSpace(value), "{") {
+		var err error
+		if content, err = ioutil.ReadFile(value); err != nil {
+			return nil, fmt.Errorf("impossible to read the JSON schema: %v", err)
+		}
+	}
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(content))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %v", err)
+	}
+	return schema, nil
 }
 
 // assertResponseXml is testing an assertion on the XML body of the response.
@@ -140,6 +297,288 @@ func (ctrl *assertionControllerImpl) assertResponseXml(assertion model.Assertion
 	return ctrl.assertResponseMap(assertion, body.Old())
 }
 
+// assertResponseXPath is testing an assertion on the XML body of the response with an XPath expression.
+func (ctrl *assertionControllerImpl) assertResponseXPath(assertion model.Assertion, bodyAsString string,
+	namespaces map[string]string) model.ResultAssertion {
+	value, found, err := evaluateXPath(assertion.Property, bodyAsString, namespaces)
+	if err != nil {
+		return model.ResultAssertion{Success: false, Message: err.Error(), Err: err, Property: assertion.Property}
+	}
+	return ctrl.assertExtractedValue(assertion, value, found)
+}
+
+// isXPath checks if the property of a response_xml source is an XPath expression,
+// otherwise it uses the dot notation (ex: root.users.user[0].name).
+func isXPath(property string) bool {
+	return strings.HasPrefix(property, "/") || strings.ContainsAny(property, "(@") || strings.Contains(property, "::")
+}
+
+// evaluateXPath returns the result of an XPath expression on an XML document.
+// A single node is returned as its string value, many nodes as a list of string values,
+// found is false if the expression returns no node.
+func evaluateXPath(expression string, bodyAsString string, namespaces map[string]string) (interface{}, bool, error) {
+	expr, err := compileXPath(expression, namespaces)
+	if err != nil {
+		return nil, false, err
+	}
+	document, err := xmlquery.Parse(strings.NewReader(bodyAsString))
+	if err != nil {
+		return nil, false, fmt.Errorf("api Response is not a valid XML: %v", err)
+	}
+
+	switch value := expr.Evaluate(xmlquery.CreateXPathNavigator(document)).(type) {
+	case *xpath.NodeIterator:
+		var values []interface{}
+		for value.MoveNext() {
+			values = append(values, value.Current().Value())
+		}
+		if len(values) == 0 {
+			return nil, false, nil
+		}
+		if len(values) == 1 {
+			return values[0], true, nil
+		}
+		return values, true, nil
+	default:
+		return value, true, nil
+	}
+}
+
+// compileXPath parses an XPath 1.0 expression, namespaces are the prefixes (and their URI) usable in the expression.
+func compileXPath(expression string, namespaces map[string]string) (*xpath.Expr, error) {
+	expr, err := xpath.CompileWithNS(expression, namespaces)
+	if err != nil {
+		return nil, fmt.Errorf("invalid XPath %q: %v", expression, err)
+	}
+	return expr, nil
+}
+
+// assertResponseHtml is testing an assertion on the HTML body of the response with a CSS selector.
+func (ctrl *assertionControllerImpl) assertResponseHtml(assertion model.Assertion, bodyAsString string) model.ResultAssertion {
+	value, found, err := evaluateCssSelector(assertion.Property, bodyAsString)
+	if err != nil {
+		return model.ResultAssertion{Success: false, Message: err.Error(), Err: err, Property: assertion.Property}
+	}
+	return ctrl.assertExtractedValue(assertion, value, found)
+}
+
+// cssPropertyRegex splits the property of a response_html source in a CSS selector
+// and an optional suffix to extract an attribute (@name) or the own text of the elements (::text).
+var cssPropertyRegex = regexp.MustCompile(`^(.*?)(@[^\s\[\]@"'=]+|::text)?$`)
+
+// compileCssProperty compiles the CSS selector of the property and returns the suffix of the property.
+func compileCssProperty(property string) (cascadia.Selector, string, error) {
+	parts := cssPropertyRegex.FindStringSubmatch(strings.TrimSpace(property))
+	selector, err := cascadia.Compile(parts[1])
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid CSS selector %q: %v", parts[1], err)
+	}
+	return selector, parts[2], nil
+}
+
+// evaluateCssSelector returns the values of the elements of an HTML document matching the property.
+// By default the value of an element is its text, the property can end with @name to use an attribute
+// or with ::text to use only the text of the element without its descendants.
+// A single value is returned as a string, many values as a list of strings,
+// found is false if there is no element with a value.
+func evaluateCssSelector(property string, bodyAsString string) (interface{}, bool, error) {
+	selector, suffix, err := compileCssProperty(property)
+	if err != nil {
+		return nil, false, err
+	}
+
+	document, err := html.Parse(strings.NewReader(bodyAsString))
+	if err != nil {
+		return nil, false, fmt.Errorf("api Response is not a valid HTML: %v", err)
+	}
+
+	var values []interface{}
+	for _, node := range selector.MatchAll(document) {
+		switch {
+		case suffix == "::text":
+			values = append(values, htmlText(node, false))
+		case len(suffix) > 0:
+			if value, ok := htmlAttr(node, strings.ToLower(suffix[1:])); ok {
+				values = append(values, value)
+			}
+		default:
+			values = append(values, htmlText(node, true))
+		}
+	}
+
+	switch len(values) {
+	case 0:
+		return nil, false, nil
+	case 1:
+		return values[0], true, nil
+	default:
+		return values, true, nil
+	}
+}
+
+// htmlAttr returns the value of an attribute of an HTML element.
+func htmlAttr(node *html.Node, name string) (string, bool) {
+	for _, attr := range node.Attr {
+		if len(attr.Namespace) == 0 && attr.Key == name {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+// htmlText returns the text of the direct text children of an HTML node, or of all its descendants if deep is true,
+// with the whitespaces collapsed.
+func htmlText(node *html.Node, deep bool) string {
+	var sb strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		switch {
+		case child.Type == html.TextNode:
+			sb.WriteString(child.Data)
+			sb.WriteString(" ")
+		case child.Type == html.ElementNode && deep:
+			sb.WriteString(htmlText(child, true))
+		}
+	}
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// cookieValue returns a value of a cookie set by the response, the property is the name of the cookie
+// for its value, or the name followed by an attribute (ex: session.domain, session.http_only).
+// If the response sets many times the cookie the last one is used.
+func cookieValue(cookies []*http.Cookie, property string) (interface{}, bool) {
+	findCookie := func(name string) *http.Cookie {
+		var found *http.Cookie
+		for _, cookie := range cookies {
+			if cookie.Name == name {
+				found = cookie
+			}
+		}
+		return found
+	}
+
+	if cookie := findCookie(property); cookie != nil {
+		return cookie.Value, true
+	}
+	index := strings.LastIndex(property, ".")
+	if index < 0 {
+		return nil, false
+	}
+	cookie := findCookie(property[:index])
+	if cookie == nil {
+		return nil, false
+	}
+
+	switch property[index+1:] {
+	case "value":
+		return cookie.Value, true
+	case "domain":
+		return cookie.Domain, true
+	case "path":
+		return cookie.Path, true
+	case "expires":
+		if cookie.Expires.IsZero() {
+			return nil, false
+		}
+		return strconv.FormatInt(cookie.Expires.Unix(), 10), true
+	case "max_age":
+		if cookie.MaxAge == 0 {
+			return nil, false
+		}
+		return strconv.Itoa(cookie.MaxAge), true
+	case "http_only":
+		return cookie.HttpOnly, true
+	case "secure":
+		return cookie.Secure, true
+	case "same_site":
+		switch cookie.SameSite {
+		case http.SameSiteLaxMode:
+			return "lax", true
+		case http.SameSiteStrictMode:
+			return "strict", true
+		case http.SameSiteNoneMode:
+			return "none", true
+		}
+	}
+	return nil, false
+}
+
+// tlsProperties are the properties of the response_tls source.
+var tlsProperties = []string{"protocol_version", "cipher_suite", "server_name", "subject", "issuer", "sans",
+	"not_after", "days_until_expiry"}
+
+// checkTLSProperty returns an error if the property is not a property of the response_tls source.
+func checkTLSProperty(property string) error {
+	for _, name := range tlsProperties {
+		if name == property {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown TLS property %q, the properties are %s", property, strings.Join(tlsProperties, ", "))
+}
+
+// assertResponseTLS is testing an assertion on the TLS connection of the response.
+func (ctrl *assertionControllerImpl) assertResponseTLS(assertion model.Assertion, info *model.TLSInfo) model.ResultAssertion {
+	value, found, err := tlsValue(info, assertion.Property, time.Now())
+	if err != nil {
+		return model.ResultAssertion{Success: false, Message: err.Error(), Err: err, Property: assertion.Property}
+	}
+	if !found && assertion.Comparison != model.IsNull {
+		message := tlsNotFoundMessage(info)
+		return model.ResultAssertion{Success: false, Message: message, Err: errors.New(message), Property: assertion.Property}
+	}
+	return ctrl.assertExtractedValue(assertion, value, found)
+}
+
+// tlsValue returns a property of the TLS connection of the response, an error is returned for an unknown property.
+// Nothing is found for a plain HTTP response, or for a certificate property if the server sent no certificate.
+// The certificate properties are the ones of the certificate of the server, days_until_expiry is computed from now
+// and is negative if the certificate has expired.
+func tlsValue(info *model.TLSInfo, property string, now time.Time) (interface{}, bool, error) {
+	if err := checkTLSProperty(property); err != nil {
+		return nil, false, err
+	}
+	if info == nil {
+		return nil, false, nil
+	}
+	switch property {
+	case "protocol_version":
+		return info.Version, true, nil
+	case "cipher_suite":
+		return info.CipherSuite, true, nil
+	case "server_name":
+		return info.ServerName, true, nil
+	}
+
+	cert := info.PeerCertificate
+	if cert == nil {
+		return nil, false, nil
+	}
+	switch property {
+	case "subject":
+		return cert.Subject, true, nil
+	case "issuer":
+		return cert.Issuer, true, nil
+	case "sans":
+		sans := make([]interface{}, 0, len(cert.SANs))
+		for _, san := range cert.SANs {
+			sans = append(sans, san)
+		}
+		return sans, true, nil
+	case "not_after":
+		return strconv.FormatInt(cert.NotAfter.Unix(), 10), true, nil
+	default: // days_until_expiry
+		return math.Floor(cert.NotAfter.Sub(now).Hours() / 24), true, nil
+	}
+}
+
+// tlsNotFoundMessage explains why a TLS property was not found in the response.
+func tlsNotFoundMessage(info *model.TLSInfo) string {
+	if info == nil {
+		return "no TLS connection, the response was received over plain HTTP"
+	}
+	return "no certificate was sent by the server"
+}
+
 func (ctrl *assertionControllerImpl) assertResponseMap(assertion model.Assertion, body map[string]interface{}) model.ResultAssertion {
 	// Convert property from Json syntax to an array of fields
 	jqPath := util.JsonConvertKeyName(assertion.Property)
@@ -149,7 +588,12 @@ func (ctrl *assertionControllerImpl) assertResponseMap(assertion model.Assertion
 
 	// Get the key in the data
 	extractedKey, err := jq.Interface(jqPath[:]...)
-	if err != nil {
+	return ctrl.assertExtractedValue(assertion, extractedKey, err == nil)
+}
+
+// assertExtractedValue is testing the value extracted from the body, found is false if the property does not exist.
+func (ctrl *assertionControllerImpl) assertExtractedValue(assertion model.Assertion, extractedKey interface{}, found bool) model.ResultAssertion {
+	if !found {
 		//If the key if not found and we are looking for is_null this is assertion Success
 		if assertion.Comparison == model.IsNull {
 			result := model.NewResultAssertion(model.IsNull, true, assertion.Property)
@@ -249,6 +693,9 @@ func (ctrl *assertionControllerImpl) assertNumber(assertion model.Assertion, api
 		success := apiValue >= testValue
 		return model.NewResultAssertion(comparison, success, apiValue, assertionValue)
 
+	case model.MatchesRegex, model.DoesNotMatchRegex:
+		return assertRegex(assertion, fmt.Sprintf("%g", apiValue))
+
 	default:
 		message := fmt.Sprintf(ComparisonNotSupportedMessage, comparison)
 		return model.ResultAssertion{Success: false, Message: message, Err: errors.New(message)}
@@ -317,6 +764,9 @@ func (ctrl *assertionControllerImpl) assertString(assertion model.Assertion, api
 		success := strings.TrimSpace(apiValue) == ""
 		return model.NewResultAssertion(comparison, success, propertyName)
 
+	case model.MatchesRegex, model.DoesNotMatchRegex:
+		return assertRegex(assertion, apiValue)
+
 	default:
 		message := fmt.Sprintf(ComparisonNotSupportedMessage, comparison)
 		return model.ResultAssertion{Success: false, Message: message, Err: errors.New(message)}
@@ -350,6 +800,9 @@ func (ctrl *assertionControllerImpl) assertBool(assertion model.Assertion, apiVa
 		}
 		return model.NewResultAssertion(comparison, !notSuccess, apiValue, assertion.Value)
 
+	case model.MatchesRegex, model.DoesNotMatchRegex:
+		return assertRegex(assertion, strconv.FormatBool(apiValue))
+
 	default:
 		message := fmt.Sprintf(ComparisonNotSupportedMessage, comparison)
 		return model.ResultAssertion{Success: false, Message: message, Err: errors.New(message)}
@@ -397,6 +850,21 @@ func (ctrl *assertionControllerImpl) assertArray(assertion model.Assertion, apiV
 		}
 		return model.NewResultAssertion(comparison, false, propertyName)
 
+	case model.MatchesRegex, model.DoesNotMatchRegex:
+		// the array matches if at least one of its items matches the regex
+		matchAssertion := assertion
+		matchAssertion.Comparison = model.MatchesRegex
+		for _, value := range apiValue {
+			assert := ctrl.assertValue(matchAssertion, value)
+			if assert.Err != nil {
+				return assert
+			}
+			if assert.Success {
+				return model.NewResultAssertion(comparison, comparison == model.MatchesRegex, propertyName, assertion.Value)
+			}
+		}
+		return model.NewResultAssertion(comparison, comparison == model.DoesNotMatchRegex, propertyName, assertion.Value)
+
 	default:
 		message := fmt.Sprintf(ComparisonNotSupportedMessage, comparison)
 		return model.ResultAssertion{Success: false, Message: message, Err: errors.New(message)}
@@ -455,10 +923,36 @@ func (ctrl *assertionControllerImpl) assertMap(assertion model.Assertion, apiVal
 }
 
 
-// patchAssertion patch the value of the assertion if there is a variable in it.
-func (ctrl *assertionControllerImpl) patchAssertion(assertion model.Assertion) model.Assertion {
-	assertion.Value = context.GetContext().Patch(assertion.Value)
-	return assertion
+// assertRegex is testing if the value "apiValue" matches the regex of the assertion.
+func assertRegex(assertion model.Assertion, apiValue string) model.ResultAssertion {
+	regex, err := regexp.Compile(assertion.Value)
+	if err != nil {
+		message := fmt.Sprintf("'%s' is not a valid regex: %v", assertion.Value, err)
+		return model.ResultAssertion{Success: false, Message: message, Err: err}
+	}
+
+	success := regex.MatchString(apiValue)
+	if assertion.Comparison == model.DoesNotMatchRegex {
+		success = !success
+	}
+	return model.NewResultAssertion(assertion.Comparison, success, apiValue, assertion.Value)
+}
+
+// patchAssertion replaces the variables in the expected value, the value is read from the value file if there is one.
+// The newlines at the end of the file are ignored.
+func (ctrl *assertionControllerImpl) patchAssertion(assertion model.Assertion, ctx *context.Context) (model.Assertion, error) {
+	if len(assertion.ValueFile) > 0 {
+		content, err := ioutil.ReadFile(ctx.Patch(assertion.ValueFile))
+		if err != nil {
+			return assertion, fmt.Errorf("impossible to read the value file: %v", err)
+		}
+		assertion.Value = strings.TrimRight(string(content), "\r\n")
+		if !util.IsText(content) {
+			return assertion, nil
+		}
+	}
+	assertion.Value = ctx.Patch(assertion.Value)
+	return assertion, nil
 }
 
 // sliceContainsValue checks if a value is contains in a slice.
@@ -21,7 +21,7 @@ type expectedResult struct {
 
 func te(t *testing.T, assertion model.Assertion, response model.Response, expected expectedResult) {
 	ctrl := controller.NewAssertionController()
-	got := ctrl.Assert(assertion, response)
+	got := ctrl.Assert(assertion, response, xmlNamespaces, context.GetContext())
 
 	if expected.err {
 		test.Ko(t, got.Err)
@@ -646,6 +646,17 @@ func TestResponseJsonEqualsStringComplexPath(t *testing.T) {
 	})
 }
 
+func TestResponseJsonEqualsStringLegacyDotIndex(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.Equal, Value: "indigo.anidter.ykxmid@test.com", Property: "emails.0.value", Source: model.ResponseJson}
+	te(t, assertion, response, expectedResult{
+		source:   model.ResponseJson,
+		message:  "'indigo.anidter.ykxmid@test.com' was equal to indigo.anidter.ykxmid@test.com",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
 func TestResponseJsonEqualsStringInvalid(t *testing.T) {
 	assertion := model.Assertion{Comparison: model.Equal, Value: "Anidter1", Property: "name.familyName", Source: model.ResponseJson}
 	te(t, assertion, response, expectedResult{
@@ -1708,6 +1719,204 @@ func TestResponseTextGreaterThanOrEqualInValid(t *testing.T) {
 }
 
 // response_xml
+func TestResponseJsonMatchesSchemaFileValid(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.MatchesSchema, Value: "../../testdata/schema/user.json", Source: model.ResponseJson}
+	te(t, assertion, response, expectedResult{
+		source:   model.ResponseJson,
+		message:  "'body' did match the JSON schema",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseJsonMatchesSchemaFileFromScenario(t *testing.T) {
+	// the test runs in pkg/controller, the schema path is relative to the scenario file
+	scenario, err := model.InitScenarioFromFile("../../testdata/schema/scenario.yml")
+	test.Ok(t, err)
+	assertion := scenario.Steps[0].Assertions[0]
+	te(t, assertion, response, expectedResult{
+		source:   model.ResponseJson,
+		message:  "'body' did match the JSON schema",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseJsonMatchesSchemaInlineInvalid(t *testing.T) {
+	assertion := model.Assertion{
+		Comparison: model.MatchesSchema,
+		Value:      `{"required": ["id", "age"], "properties": {"point": {"type": "string"}, "emails": {"items": {"properties": {"primary": {"const": false}}}}}}`,
+		Source:     model.ResponseJson,
+	}
+	te(t, assertion, response, expectedResult{
+		source:   model.ResponseJson,
+		message:  "'body' did not match the JSON schema: (root): age is required, emails.0.primary: emails.0.primary does not match: false, point: Invalid type. Expected: string, given: integer",
+		property: assertion.Property,
+		success:  false,
+		err:      false,
+	})
+}
+
+func TestResponseJsonMatchesSchemaProperty(t *testing.T) {
+	assertion := model.Assertion{
+		Comparison: model.MatchesSchema,
+		Property:   "name",
+		Value:      `{"type": "object", "required": ["familyName"], "properties": {"familyName": {"type": "string"}, "givenName": {"type": "string"}}, "additionalProperties": false}`,
+		Source:     model.ResponseJson,
+	}
+	te(t, assertion, response, expectedResult{
+		source:   model.ResponseJson,
+		message:  "'name' did match the JSON schema",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseJsonMatchesSchemaFileNotFound(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.MatchesSchema, Value: "unknown.json", Source: model.ResponseJson}
+	te(t, assertion, response, expectedResult{
+		source:   model.ResponseJson,
+		message:  "impossible to read the JSON schema: open unknown.json: no such file or directory",
+		property: assertion.Property,
+		success:  false,
+		err:      true,
+	})
+}
+
+func TestResponseStatusMatchesRegexValid(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.MatchesRegex, Value: `^2\d\d$`, Source: model.ResponseStatus}
+	te(t, assertion, model.Response{StatusCode: http.StatusCreated}, expectedResult{
+		source:  model.ResponseStatus,
+		message: "'201' did match the regex ^2\\d\\d$",
+		success: true,
+		err:     false,
+	})
+}
+
+func TestResponseTextDoesNotMatchRegexInvalid(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.DoesNotMatchRegex, Value: `(?i)error`, Source: model.ResponseText}
+	te(t, assertion, model.Response{Body: "Internal Error"}, expectedResult{
+		source:   model.ResponseText,
+		message:  "'Internal Error' did match the regex (?i)error",
+		success:  false,
+		err:      false,
+	})
+}
+
+func TestResponseHeaderMatchesRegexValid(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.MatchesRegex, Property: "Content-Type", Value: `^application/(json|xml)`, Source: model.ResponseHeader}
+	response := model.Response{Header: http.Header{"Content-Type": {"application/json; charset=utf-8"}}}
+	te(t, assertion, response, expectedResult{
+		source:   model.ResponseHeader,
+		message:  "'application/json; charset=utf-8' did match the regex ^application/(json|xml)",
+		property: "Content-Type",
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseJsonMatchesRegexArray(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.MatchesRegex, Property: "schemas", Value: `:User$`, Source: model.ResponseJson}
+	te(t, assertion, response, expectedResult{
+		source:   model.ResponseJson,
+		message:  "'schemas' did match the regex :User$",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseJsonDoesNotMatchRegexBool(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.DoesNotMatchRegex, Property: "active", Value: `^false$`, Source: model.ResponseJson}
+	te(t, assertion, response, expectedResult{
+		source:   model.ResponseJson,
+		message:  "'true' did not match the regex ^false$",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseJsonMatchesRegexInvalidRegex(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.MatchesRegex, Property: "userName", Value: `(`, Source: model.ResponseJson}
+	te(t, assertion, response, expectedResult{
+		source:   model.ResponseJson,
+		message:  "'(' is not a valid regex: error parsing regexp: missing closing ): `(`",
+		property: assertion.Property,
+		success:  false,
+		err:      true,
+	})
+}
+
+func TestResponseJsonJsonPathListEqualNotSupported(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.Equal, Property: "$.emails[?(@.primary == true)].value", Value: "indigo.anidter.ykxmid@test.com", Source: model.ResponseJson}
+	te(t, assertion, response, expectedResult{
+		source:   model.ResponseJson,
+		message:  "the comparison equal was not supported for the source",
+		property: assertion.Property,
+		success:  false,
+		err:      true,
+	})
+}
+
+func TestResponseJsonJsonPathListContains(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.Contains, Property: "$.emails[?(@.primary == true)].value", Value: "indigo.anidter.ykxmid@test.com", Source: model.ResponseJson}
+	te(t, assertion, response, expectedResult{
+		source:   model.ResponseJson,
+		message:  "'$.emails[?(@.primary == true)].value' does contains indigo.anidter.ykxmid@test.com",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseJsonJsonPathListEmpty(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.Empty, Property: "$..[?(@.primary == false)]", Source: model.ResponseJson}
+	te(t, assertion, response, expectedResult{
+		source:   model.ResponseJson,
+		message:  "'$..[?(@.primary == false)]' was empty",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseJsonJsonPathNegativeIndex(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.EqualNumber, Property: "$.externalIds[-1]", Value: "6", Source: model.ResponseJson}
+	te(t, assertion, response, expectedResult{
+		source:   model.ResponseJson,
+		message:  "'6' was a number equal to 6",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseJsonJsonPathRootArray(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.HasValue, Property: "$[*].name", Value: "john", Source: model.ResponseJson}
+	te(t, assertion, model.Response{Body: `[{"name": "jane"}, {"name": "john"}]`}, expectedResult{
+		source:   model.ResponseJson,
+		message:  "'$[*].name' had value",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseJsonJsonPathInvalid(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.NotEmpty, Property: "$.emails[", Source: model.ResponseJson}
+	te(t, assertion, response, expectedResult{
+		source:   model.ResponseJson,
+		message:  "invalid JSONPath \"$.emails[\": unexpected end of file",
+		property: assertion.Property,
+		success:  false,
+		err:      true,
+	})
+}
+
 var bodyXml = `<?xml version="1.0" encoding="UTF-8"?>
 <root>
    <active>true</active>
@@ -2466,3 +2675,405 @@ func TestResponseXmlEmptyBody(t *testing.T) {
 		err:      true,
 	})
 }
+
+// response_xml with XPath
+
+var xmlNamespaces = map[string]string{"soap": "http://schemas.xmlsoap.org/soap/envelope/", "u": "urn:users"}
+
+var responseSoap = model.Response{
+	Body: `<?xml version="1.0" encoding="UTF-8"?>
+<env:Envelope xmlns:env="http://schemas.xmlsoap.org/soap/envelope/">
+	<env:Body>
+		<GetUserResponse xmlns="urn:users">
+			<user id="42" status="active"><name>john</name></user>
+		</GetUserResponse>
+	</env:Body>
+</env:Envelope>`,
+}
+
+func TestResponseXmlXPathAttribute(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.Equal, Property: "/root/building/@null", Value: "true", Source: model.ResponseXml}
+	te(t, assertion, responseXml, expectedResult{
+		source:   model.ResponseXml,
+		message:  "'true' was equal to true",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseXmlXPathCount(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.EqualNumber, Property: "count(//emails/email)", Value: "2", Source: model.ResponseXml}
+	te(t, assertion, responseXml, expectedResult{
+		source:   model.ResponseXml,
+		message:  "'2' was a number equal to 2",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseXmlXPathText(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.Equal, Property: "//email[primary='false']/value/text()", Value: "indigo.anidter.ykxmid2@test.com", Source: model.ResponseXml}
+	te(t, assertion, responseXml, expectedResult{
+		source:   model.ResponseXml,
+		message:  "'indigo.anidter.ykxmid2@test.com' was equal to indigo.anidter.ykxmid2@test.com",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseXmlXPathList(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.HasValue, Property: "//email/value", Value: "indigo.anidter.ykxmid@test.com", Source: model.ResponseXml}
+	te(t, assertion, responseXml, expectedResult{
+		source:   model.ResponseXml,
+		message:  "'//email/value' had value",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseXmlXPathIsNull(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.IsNull, Property: "/root/unknown", Source: model.ResponseXml}
+	te(t, assertion, responseXml, expectedResult{
+		source:   model.ResponseXml,
+		message:  "'/root/unknown' was null",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseXmlXPathNamespaces(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.Equal, Property: "/soap:Envelope/soap:Body/u:GetUserResponse/u:user[@status='active']/@id", Value: "42", Source: model.ResponseXml}
+	te(t, assertion, responseSoap, expectedResult{
+		source:   model.ResponseXml,
+		message:  "'42' was equal to 42",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseXmlXPathPrefixedElementWithoutPrefix(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.IsNull, Property: "/Envelope", Source: model.ResponseXml}
+	te(t, assertion, responseSoap, expectedResult{
+		source:   model.ResponseXml,
+		message:  "'/Envelope' was null",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseXmlXPathUndeclaredPrefix(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.NotEmpty, Property: "/ns:Envelope", Source: model.ResponseXml}
+	te(t, assertion, responseSoap, expectedResult{
+		source:   model.ResponseXml,
+		message:  "invalid XPath \"/ns:Envelope\": prefix ns not defined.",
+		property: assertion.Property,
+		success:  false,
+		err:      true,
+	})
+}
+
+// response_html
+
+var responseHtml = model.Response{
+	Body: `<!DOCTYPE html>
+<html>
+<body>
+	<h1 class="title">Welcome <small>john</small></h1>
+	<form action="/login" method="post">
+		<input type="hidden" name="csrf_token" value="a1b2c3">
+		<input type="email" name="email" required>
+	</form>
+	<ul>
+		<li>admin<li>user<li>guest
+	</ul>
+</body>
+</html>`,
+}
+
+func TestResponseHtmlText(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.Equal, Property: "h1.title", Value: "Welcome john", Source: model.ResponseHtml}
+	te(t, assertion, responseHtml, expectedResult{
+		source:   model.ResponseHtml,
+		message:  "'Welcome john' was equal to Welcome john",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseHtmlOwnText(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.Equal, Property: "h1.title::text", Value: "Welcome", Source: model.ResponseHtml}
+	te(t, assertion, responseHtml, expectedResult{
+		source:   model.ResponseHtml,
+		message:  "'Welcome' was equal to Welcome",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseHtmlAttribute(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.MatchesRegex, Property: "form input[name=csrf_token]@value", Value: "^[a-z0-9]{6}$", Source: model.ResponseHtml}
+	te(t, assertion, responseHtml, expectedResult{
+		source:   model.ResponseHtml,
+		message:  "'a1b2c3' did match the regex ^[a-z0-9]{6}$",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseHtmlList(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.HasValue, Property: "ul > li", Value: "guest", Source: model.ResponseHtml}
+	te(t, assertion, responseHtml, expectedResult{
+		source:   model.ResponseHtml,
+		message:  "'ul > li' had value",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseHtmlMissingAttribute(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.IsNull, Property: "input[type=email]@value", Source: model.ResponseHtml}
+	te(t, assertion, responseHtml, expectedResult{
+		source:   model.ResponseHtml,
+		message:  "'input[type=email]@value' was null",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseHtmlInvalidSelector(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.NotEmpty, Property: "ul >", Source: model.ResponseHtml}
+	te(t, assertion, responseHtml, expectedResult{
+		source:   model.ResponseHtml,
+		message:  "invalid CSS selector \"ul >\": expected selector, found EOF instead",
+		property: assertion.Property,
+		success:  false,
+		err:      true,
+	})
+}
+
+// response_cookie
+
+var responseCookie = model.Response{
+	Cookies: []*http.Cookie{
+		{Name: "session", Value: "abc", Domain: "test.com", Path: "/", HttpOnly: true, Expires: time.Unix(1600000000, 0)},
+		{Name: "lang", Value: "fr", SameSite: http.SameSiteLaxMode},
+	},
+}
+
+func TestResponseCookieValue(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.Equal, Property: "session", Value: "abc", Source: model.ResponseCookie}
+	te(t, assertion, responseCookie, expectedResult{
+		source:   model.ResponseCookie,
+		message:  "'abc' was equal to abc",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseCookieDomain(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.Equal, Property: "session.domain", Value: "test.com", Source: model.ResponseCookie}
+	te(t, assertion, responseCookie, expectedResult{
+		source:   model.ResponseCookie,
+		message:  "'test.com' was equal to test.com",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseCookieExpires(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.IsGreaterThan, Property: "session.expires", Value: "1500000000", Source: model.ResponseCookie}
+	te(t, assertion, responseCookie, expectedResult{
+		source:   model.ResponseCookie,
+		message:  "'1600000000' was greater than 1500000000",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseCookieFlags(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.Equal, Property: "session.secure", Value: "false", Source: model.ResponseCookie}
+	te(t, assertion, responseCookie, expectedResult{
+		source:   model.ResponseCookie,
+		message:  "'false' was equal to false",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseCookieSameSite(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.Equal, Property: "lang.same_site", Value: "lax", Source: model.ResponseCookie}
+	te(t, assertion, responseCookie, expectedResult{
+		source:   model.ResponseCookie,
+		message:  "'lax' was equal to lax",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseCookieNotSet(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.IsNull, Property: "token", Source: model.ResponseCookie}
+	te(t, assertion, responseCookie, expectedResult{
+		source:   model.ResponseCookie,
+		message:  "'token' was null",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+// response_tls
+
+var responseTLS = model.Response{
+	TLS: &model.TLSInfo{
+		Version:     "TLS 1.3",
+		CipherSuite: "TLS_AES_128_GCM_SHA256",
+		PeerCertificate: &model.CertificateInfo{
+			Subject:  "CN=api.test.com",
+			Issuer:   "CN=R3,O=Let's Encrypt,C=US",
+			SANs:     []string{"api.test.com", "www.test.com"},
+			NotAfter: time.Now().Add(30*24*time.Hour + time.Hour),
+		},
+	},
+}
+
+func TestResponseTLSDaysUntilExpiry(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.IsGreaterThan, Property: "days_until_expiry", Value: "14", Source: model.ResponseTLS}
+	te(t, assertion, responseTLS, expectedResult{
+		source:   model.ResponseTLS,
+		message:  "'30' was greater than 14",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseTLSExpired(t *testing.T) {
+	expired := model.Response{TLS: &model.TLSInfo{PeerCertificate: &model.CertificateInfo{NotAfter: time.Now().Add(-36 * time.Hour)}}}
+	assertion := model.Assertion{Comparison: model.IsGreaterThan, Property: "days_until_expiry", Value: "0", Source: model.ResponseTLS}
+	te(t, assertion, expired, expectedResult{
+		source:   model.ResponseTLS,
+		message:  "'-2' was not greater than 0",
+		property: assertion.Property,
+		success:  false,
+		err:      false,
+	})
+}
+
+func TestResponseTLSIssuer(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.Contains, Property: "issuer", Value: "O=Let's Encrypt", Source: model.ResponseTLS}
+	te(t, assertion, responseTLS, expectedResult{
+		source:   model.ResponseTLS,
+		message:  "'CN=R3,O=Let's Encrypt,C=US' does contains O=Let's Encrypt",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseTLSSANs(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.Contains, Property: "sans", Value: "www.test.com", Source: model.ResponseTLS}
+	te(t, assertion, responseTLS, expectedResult{
+		source:   model.ResponseTLS,
+		message:  "'sans' does contains www.test.com",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseTLSProtocolVersion(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.Equal, Property: "protocol_version", Value: "TLS 1.3", Source: model.ResponseTLS}
+	te(t, assertion, responseTLS, expectedResult{
+		source:   model.ResponseTLS,
+		message:  "'TLS 1.3' was equal to TLS 1.3",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseTLSPlainHTTP(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.Equal, Property: "subject", Value: "CN=api.test.com", Source: model.ResponseTLS}
+	te(t, assertion, model.Response{}, expectedResult{
+		source:   model.ResponseTLS,
+		message:  "no TLS connection, the response was received over plain HTTP",
+		property: assertion.Property,
+		success:  false,
+		err:      true,
+	})
+}
+
+func TestResponseTLSPlainHTTPIsNull(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.IsNull, Property: "protocol_version", Source: model.ResponseTLS}
+	te(t, assertion, model.Response{}, expectedResult{
+		source:   model.ResponseTLS,
+		message:  "'protocol_version' was null",
+		property: assertion.Property,
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestResponseTLSNoCertificate(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.Equal, Property: "issuer", Value: "CN=Test CA", Source: model.ResponseTLS}
+	te(t, assertion, model.Response{TLS: &model.TLSInfo{Version: "TLS 1.3"}}, expectedResult{
+		source:   model.ResponseTLS,
+		message:  "no certificate was sent by the server",
+		property: assertion.Property,
+		success:  false,
+		err:      true,
+	})
+}
+
+func TestResponseTLSUnknownProperty(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.NotEmpty, Property: "expiry", Source: model.ResponseTLS}
+	te(t, assertion, responseTLS, expectedResult{
+		source:   model.ResponseTLS,
+		message:  "unknown TLS property \"expiry\", the properties are protocol_version, cipher_suite, server_name, subject, issuer, sans, not_after, days_until_expiry",
+		property: assertion.Property,
+		success:  false,
+		err:      true,
+	})
+}
+
+// value_file
+
+func TestAssertionValueFile(t *testing.T) {
+	context.GetContext().Add("name", "john")
+	assertion := model.Assertion{Comparison: model.Equal, ValueFile: "../../testdata/upload/welcome.txt", Source: model.ResponseText}
+	te(t, assertion, model.Response{Body: "Welcome john"}, expectedResult{
+		source:   model.ResponseText,
+		message:  "'Welcome john' was equal to Welcome john",
+		property: "",
+		success:  true,
+		err:      false,
+	})
+}
+
+func TestAssertionValueFileNotFound(t *testing.T) {
+	assertion := model.Assertion{Comparison: model.Equal, ValueFile: "../../testdata/upload/unknown.txt", Source: model.ResponseText}
+	te(t, assertion, model.Response{Body: "Welcome john"}, expectedResult{
+		source:   model.ResponseText,
+		message:  "impossible to read the value file: open ../../testdata/upload/unknown.txt: no such file or directory",
+		property: "",
+		success:  false,
+		err:      true,
+	})
+}
@@ -0,0 +1,335 @@
+package controller
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sendgrid/rest"
+	"github.com/thomaspoignant/api-scenario/pkg/context"
+	"github.com/thomaspoignant/api-scenario/pkg/model"
+	"github.com/thomaspoignant/api-scenario/pkg/secret"
+	"github.com/thomaspoignant/api-scenario/pkg/sigv4"
+)
+
+// requestAuth returns the authentication of the step, or the one of the scenario if the step has none.
+func requestAuth(step model.Step, ctx *context.Context) *model.Auth {
+	if step.Auth != nil {
+		return step.Auth
+	}
+	return ctx.Auth()
+}
+
+// authenticate adds the credentials of the authentication to the request.
+// The digest authentication needs a challenge of the server, the credentials are added by reauthenticate.
+// The AWS signature is computed on the final request, so the request should not be modified after.
+// It returns the OAuth2 access token used, or an empty string.
+func (sc *stepControllerImpl) authenticate(req *rest.Request, auth *model.Auth, stepHeaders map[string][]string,
+	timeout time.Duration, ctx *context.Context) (string, error) {
+	if auth == nil {
+		return "", nil
+	}
+	if req.Headers == nil {
+		req.Headers = map[string]string{}
+	}
+	// An Authorization header set explicitly by the step is kept, the one of the command line
+	// (--authorization-token, --header or environment files) is replaced by the credentials.
+	hasAuthorization := false
+	for key, values := range stepHeaders {
+		if strings.EqualFold(key, "Authorization") && len(values) > 0 && len(values[0]) > 0 {
+			hasAuthorization = true
+		}
+	}
+	if !hasAuthorization && usesAuthorizationHeader(auth) && len(headerValue(req.Headers, "Authorization")) > 0 {
+		ctx.Logger().Debug("The Authorization header of the command line is replaced by the authentication")
+		deleteHeader(req.Headers, "Authorization")
+	}
+
+	switch {
+	case auth.Type == model.AuthOAuth2 && auth.OAuth2 != nil && !hasAuthorization:
+		return sc.authorizeOAuth2(req, *auth.OAuth2, "", timeout, ctx)
+
+	case auth.Type == model.AuthBasic && auth.Basic != nil && !hasAuthorization:
+		credentials, err := patchCredentials(*auth.Basic, ctx)
+		if err != nil {
+			return "", err
+		}
+		req.Headers["Authorization"] = "Basic " +
+			base64.StdEncoding.EncodeToString([]byte(credentials.Username+":"+credentials.Password))
+
+	case auth.Type == model.AuthAWSSigV4 && auth.AWS != nil && !hasAuthorization:
+		return "", signAWS(req, *auth.AWS, ctx)
+
+	case auth.Type == model.AuthAPIKey && auth.APIKey != nil:
+		name, nameErr := ctx.PatchWithError(auth.APIKey.Name)
+		value, valueErr := ctx.PatchWithError(auth.APIKey.Value)
+		if err := joinErrors(nameErr, valueErr); err != nil {
+			return "", fmt.Errorf("impossible to apply the API key: %v", err)
+		}
+		secret.GetRegistry().AddValue(value)
+		if auth.APIKey.In == model.APIKeyInQuery {
+			if req.QueryParams == nil {
+				req.QueryParams = map[string]string{}
+			}
+			req.QueryParams[name] = value
+		} else {
+			req.Headers[name] = value
+		}
+	}
+	return "", nil
+}
+
+// usesAuthorizationHeader checks if the credentials of the authentication are sent in the Authorization header.
+func usesAuthorizationHeader(auth *model.Auth) bool {
+	switch auth.Type {
+	case model.AuthBasic:
+		return auth.Basic != nil
+	case model.AuthDigest:
+		return auth.Digest != nil
+	case model.AuthOAuth2:
+		return auth.OAuth2 != nil
+	case model.AuthAWSSigV4:
+		return auth.AWS != nil
+	}
+	return false
+}
+
+// reauthenticate changes the credentials of a request refused with a 401 status.
+// The OAuth2 access token is renewed and the digest authentication answers the challenge of the server.
+// It returns true if the request should be sent again.
+func (sc *stepControllerImpl) reauthenticate(req *rest.Request, auth *model.Auth, res *rest.Response,
+	accessToken string, timeout time.Duration, ctx *context.Context) (bool, error) {
+	if auth == nil || res.StatusCode != http.StatusUnauthorized {
+		return false, nil
+	}
+
+	switch {
+	case auth.Type == model.AuthOAuth2 && len(accessToken) > 0:
+		ctx.Logger().Info("The access token was refused, retrying with a new token")
+		_, err := sc.authorizeOAuth2(req, *auth.OAuth2, accessToken, timeout, ctx)
+		return err == nil, err
+
+	case auth.Type == model.AuthDigest && auth.Digest != nil && len(headerValue(req.Headers, "Authorization")) == 0:
+		challenge, ok := digestChallenge(http.Header(res.Headers))
+		if !ok {
+			return false, nil
+		}
+		credentials, err := patchCredentials(*auth.Digest, ctx)
+		if err != nil {
+			return false, err
+		}
+		authorization, err := digestAuthorization(*req, credentials, challenge)
+		if err != nil {
+			return false, err
+		}
+		req.Headers["Authorization"] = authorization
+		return true, nil
+	}
+	return false, nil
+}
+
+// signAWS signs the request with the AWS Signature Version 4, the request should not be modified after.
+func signAWS(req *rest.Request, config model.AWSSigV4, ctx *context.Context) error {
+	var errs []error
+	value := func(s string, envVariables ...string) string {
+		if len(s) == 0 {
+			for _, name := range envVariables {
+				if env, ok := os.LookupEnv(name); ok && len(env) > 0 {
+					return env
+				}
+			}
+			return ""
+		}
+		patched, err := ctx.PatchWithError(s)
+		errs = append(errs, err)
+		return patched
+	}
+
+	credentials := sigv4.Credentials{
+		AccessKeyID:     value(config.AccessKeyID, "AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: value(config.SecretAccessKey, "AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    value(config.SessionToken, "AWS_SESSION_TOKEN"),
+	}
+	region := value(config.Region, "AWS_REGION", "AWS_DEFAULT_REGION")
+	service := value(config.Service)
+	if err := joinErrors(errs...); err != nil {
+		return fmt.Errorf("impossible to sign the request: %v", err)
+	}
+	if len(credentials.AccessKeyID) == 0 || len(credentials.SecretAccessKey) == 0 || len(region) == 0 || len(service) == 0 {
+		return fmt.Errorf("impossible to sign the request: the AWS access key, secret key, region and service are needed")
+	}
+	secret.GetRegistry().AddValue(credentials.SecretAccessKey)
+	secret.GetRegistry().AddValue(credentials.SessionToken)
+
+	httpReq, err := rest.BuildRequestObject(*req)
+	if err != nil {
+		return err
+	}
+	sigv4.Sign(httpReq, req.Body, credentials, region, service, time.Now())
+	for _, name := range []string{"X-Amz-Date", "X-Amz-Security-Token", "X-Amz-Content-Sha256", "Authorization"} {
+		if value := httpReq.Header.Get(name); len(value) > 0 {
+			req.Headers[name] = value
+		}
+	}
+	return nil
+}
+
+// patchCredentials replaces the variables in the credentials, the password is registered as a secret.
+func patchCredentials(credentials model.Credentials, ctx *context.Context) (model.Credentials, error) {
+	username, usernameErr := ctx.PatchWithError(credentials.Username)
+	password, passwordErr := ctx.PatchWithError(credentials.Password)
+	if err := joinErrors(usernameErr, passwordErr); err != nil {
+		return model.Credentials{}, fmt.Errorf("impossible to apply the credentials: %v", err)
+	}
+	secret.GetRegistry().AddValue(password)
+	return model.Credentials{Username: username, Password: password}, nil
+}
+
+// joinErrors returns an error with the messages of all the errors, nil if there is no error.
+func joinErrors(errs ...error) error {
+	var messages []string
+	for _, err := range errs {
+		if err != nil {
+			messages = append(messages, err.Error())
+		}
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(messages, ", "))
+}
+
+// digestChallenge returns the parameters of the digest challenge of the WWW-Authenticate headers.
+func digestChallenge(headers http.Header) (map[string]string, bool) {
+	for _, header := range headers.Values("WWW-Authenticate") {
+		header = strings.TrimSpace(header)
+		if len(header) > 7 && strings.EqualFold(header[:7], "Digest ") {
+			return parseAuthParams(header[7:]), true
+		}
+	}
+	return nil, false
+}
+
+// parseAuthParams parses the comma separated key=value parameters of a challenge, the values can be quoted.
+func parseAuthParams(s string) map[string]string {
+	params := map[string]string{}
+	for len(s) > 0 {
+		s = strings.TrimLeft(s, " ,")
+		equal := strings.Index(s, "=")
+		if equal < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:equal]))
+		s = strings.TrimSpace(s[equal+1:])
+
+		var value strings.Builder
+		if strings.HasPrefix(s, `"`) {
+			i := 1
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				value.WriteByte(s[i])
+			}
+			if i < len(s) {
+				i++
+			}
+			s = s[i:]
+		} else {
+			end := strings.Index(s, ",")
+			if end < 0 {
+				end = len(s)
+			}
+			value.WriteString(strings.TrimSpace(s[:end]))
+			s = s[end:]
+		}
+		params[key] = value.String()
+	}
+	return params
+}
+
+// digestAuthorization computes the Authorization header answering a digest challenge (RFC 7616).
+// The algorithms MD5 and SHA-256 (and their -sess variants) and the qop auth and auth-int are supported.
+func digestAuthorization(req rest.Request, credentials model.Credentials, challenge map[string]string) (string, error) {
+	algorithm := challenge["algorithm"]
+	if len(algorithm) == 0 {
+		algorithm = "MD5"
+	}
+	var newHash func() hash.Hash
+	switch strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS") {
+	case "MD5":
+		newHash = md5.New
+	case "SHA-256":
+		newHash = sha256.New
+	default:
+		return "", fmt.Errorf("the digest algorithm %s is not supported", algorithm)
+	}
+	h := func(s string) string {
+		digest := newHash()
+		digest.Write([]byte(s))
+		return hex.EncodeToString(digest.Sum(nil))
+	}
+
+	httpReq, err := rest.BuildRequestObject(req)
+	if err != nil {
+		return "", err
+	}
+	uri := httpReq.URL.RequestURI()
+	realm, nonce := challenge["realm"], challenge["nonce"]
+
+	cnonceBytes := make([]byte, 16)
+	if _, err := rand.Read(cnonceBytes); err != nil {
+		return "", err
+	}
+	cnonce := hex.EncodeToString(cnonceBytes)
+	const nc = "00000001"
+
+	qop := ""
+	for _, option := range strings.Split(challenge["qop"], ",") {
+		option = strings.TrimSpace(option)
+		if option == "auth" || (option == "auth-int" && len(qop) == 0) {
+			qop = option
+		}
+	}
+
+	ha1 := h(credentials.Username + ":" + realm + ":" + credentials.Password)
+	if strings.HasSuffix(strings.ToUpper(algorithm), "-SESS") {
+		ha1 = h(ha1 + ":" + nonce + ":" + cnonce)
+	}
+	ha2 := h(httpReq.Method + ":" + uri)
+	if qop == "auth-int" {
+		ha2 = h(httpReq.Method + ":" + uri + ":" + h(string(req.Body)))
+	}
+
+	var response string
+	if len(qop) > 0 {
+		response = h(strings.Join([]string{ha1, nonce, nc, cnonce, qop, ha2}, ":"))
+	} else {
+		response = h(ha1 + ":" + nonce + ":" + ha2)
+	}
+
+	params := []string{
+		fmt.Sprintf(`username="%s"`, credentials.Username),
+		fmt.Sprintf(`realm="%s"`, realm),
+		fmt.Sprintf(`nonce="%s"`, nonce),
+		fmt.Sprintf(`uri="%s"`, uri),
+		fmt.Sprintf(`algorithm=%s`, algorithm),
+		fmt.Sprintf(`response="%s"`, response),
+	}
+	if len(qop) > 0 {
+		params = append(params, fmt.Sprintf(`qop=%s`, qop), fmt.Sprintf(`nc=%s`, nc), fmt.Sprintf(`cnonce="%s"`, cnonce))
+	}
+	if opaque, ok := challenge["opaque"]; ok {
+		params = append(params, fmt.Sprintf(`opaque="%s"`, opaque))
+	}
+	return "Digest " + strings.Join(params, ", "), nil
+}
@@ -0,0 +1,284 @@
+package controller_test
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/tls"
+	"fmt"
+	"hash"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sendgrid/rest"
+	"github.com/spf13/viper"
+	"github.com/thomaspoignant/api-scenario/pkg/context"
+	"github.com/thomaspoignant/api-scenario/pkg/controller"
+	"github.com/thomaspoignant/api-scenario/pkg/model"
+	"github.com/thomaspoignant/api-scenario/pkg/secret"
+	"github.com/thomaspoignant/api-scenario/pkg/sigv4"
+	"github.com/thomaspoignant/api-scenario/test"
+)
+
+// authClientMock records the requests and accepts them, except with digestAlgorithm where it checks
+// the digest authentication of john/p4ss.
+type authClientMock struct {
+	digestAlgorithm string
+	requests        []rest.Request
+}
+
+var digestParamRegex = regexp.MustCompile(`(\w+)="?([^",]*)"?`)
+
+func (c *authClientMock) Send(request rest.Request, timeout time.Duration, jar http.CookieJar,
+	tlsConfig *model.TLSConfig) (*rest.Response, *tls.ConnectionState, error) {
+	c.requests = append(c.requests, request)
+	if len(c.digestAlgorithm) == 0 {
+		return &rest.Response{StatusCode: 200}, nil, nil
+	}
+
+	unauthorized := &rest.Response{
+		StatusCode: 401,
+		Headers: map[string][]string{
+			"Www-Authenticate": {
+				`Basic realm="test"`,
+				`Digest realm="test@host.com", qop="auth,auth-int", algorithm=` + c.digestAlgorithm +
+					`, nonce="dcd98b7102dd2f0e8b11d0f600bfb0c093", opaque="5ccc069c403ebaf9f0171e9517f40e41"`,
+			},
+		},
+	}
+	authorization := request.Headers["Authorization"]
+	if !strings.HasPrefix(authorization, "Digest ") {
+		return unauthorized, nil, nil
+	}
+
+	params := map[string]string{}
+	for _, match := range digestParamRegex.FindAllStringSubmatch(authorization, -1) {
+		params[match[1]] = match[2]
+	}
+	newHash := md5.New
+	if c.digestAlgorithm == "SHA-256" {
+		newHash = sha256.New
+	}
+	h := func(s string) string {
+		var digest hash.Hash = newHash()
+		digest.Write([]byte(s))
+		return fmt.Sprintf("%x", digest.Sum(nil))
+	}
+	ha1 := h("john:" + params["realm"] + ":p4ss")
+	ha2 := h(string(request.Method) + ":" + params["uri"])
+	want := h(strings.Join([]string{ha1, params["nonce"], params["nc"], params["cnonce"], params["qop"], ha2}, ":"))
+	if params["response"] != want || params["opaque"] != "5ccc069c403ebaf9f0171e9517f40e41" {
+		return unauthorized, nil, nil
+	}
+	return &rest.Response{StatusCode: 200}, nil, nil
+}
+
+var authStep = model.Step{
+	StepType: model.RequestStep,
+	URL:      "https://api.test.com/users?page=2",
+	Method:   "GET",
+	Assertions: []model.Assertion{
+		{Source: model.ResponseStatus, Comparison: model.EqualNumber, Value: "200"},
+	},
+}
+
+func TestAuthBasic(t *testing.T) {
+	test.SetupLog()
+	client := &authClientMock{}
+	sc := controller.NewStepController(client, controller.NewAssertionController())
+	ctx := context.NewContext()
+	ctx.Add("user", "john")
+
+	step := authStep
+	step.Auth = &model.Auth{Type: model.AuthBasic, Basic: &model.Credentials{Username: "{{user}}", Password: "p4ss"}}
+	got, err := sc.Run(step, ctx)
+	test.Ok(t, err)
+	test.Equals(t, "Step should be a success", true, got.IsSuccess())
+	test.Equals(t, "Should send the basic credentials", "Basic am9objpwNHNz", client.requests[0].Headers["Authorization"])
+	test.Equals(t, "Should record the request with the credentials", "Basic am9objpwNHNz", got.Request.Headers["Authorization"])
+}
+
+func TestAuthAPIKey(t *testing.T) {
+	test.SetupLog()
+	defer secret.GetRegistry().Reset()
+	client := &authClientMock{}
+	sc := controller.NewStepController(client, controller.NewAssertionController())
+	ctx := context.NewContext()
+	ctx.Add("key", "k3y")
+
+	step := authStep
+	step.Auth = &model.Auth{Type: model.AuthAPIKey, APIKey: &model.APIKey{Name: "X-Key", Value: "{{key}}"}}
+	_, err := sc.Run(step, ctx)
+	test.Ok(t, err)
+	test.Equals(t, "Should send the key in a header", "k3y", client.requests[0].Headers["X-Key"])
+
+	step.Auth.APIKey.In = model.APIKeyInQuery
+	output := test.CaptureOutput(func() {
+		_, err = sc.Run(step, ctx)
+	})
+	test.Ok(t, err)
+	test.Equals(t, "Should send the key in the query", map[string]string{"page": "2", "X-Key": "k3y"}, client.requests[1].QueryParams)
+	test.Assert(t, !strings.Contains(output, "k3y"), "the key should be masked: %s", output)
+}
+
+func TestAuthDigest(t *testing.T) {
+	test.SetupLog()
+	for _, algorithm := range []string{"MD5", "SHA-256"} {
+		t.Run(algorithm, func(t *testing.T) {
+			client := &authClientMock{digestAlgorithm: algorithm}
+			sc := controller.NewStepController(client, controller.NewAssertionController())
+
+			step := authStep
+			step.Auth = &model.Auth{Type: model.AuthDigest, Digest: &model.Credentials{Username: "john", Password: "p4ss"}}
+			got, err := sc.Run(step, context.NewContext())
+			test.Ok(t, err)
+			test.Equals(t, "Step should be a success", true, got.IsSuccess())
+			test.Equals(t, "Should answer the challenge", 2, len(client.requests))
+			test.Assert(t, strings.Contains(got.Request.Headers["Authorization"], `uri="/users?page=2"`),
+				"Should record the request with the digest: %s", got.Request.Headers["Authorization"])
+		})
+	}
+}
+
+func TestAuthDigestWrongPassword(t *testing.T) {
+	test.SetupLog()
+	client := &authClientMock{digestAlgorithm: "MD5"}
+	sc := controller.NewStepController(client, controller.NewAssertionController())
+
+	step := authStep
+	step.Auth = &model.Auth{Type: model.AuthDigest, Digest: &model.Credentials{Username: "john", Password: "wrong"}}
+	got, err := sc.Run(step, context.NewContext())
+	test.Ok(t, err)
+	test.Equals(t, "Step should be failed", false, got.IsSuccess())
+	test.Equals(t, "Should answer the challenge only once", 2, len(client.requests))
+}
+
+func TestAuthStepReplacesScenarioAuth(t *testing.T) {
+	test.SetupLog()
+	client := &authClientMock{}
+	sc := controller.NewStepController(client, controller.NewAssertionController())
+	ctx := context.NewContext()
+	ctx.SetAuth(&model.Auth{Type: model.AuthBasic, Basic: &model.Credentials{Username: "john", Password: "p4ss"}})
+
+	_, err := sc.Run(authStep, ctx)
+	test.Ok(t, err)
+	test.Equals(t, "Should use the auth of the scenario", "Basic am9objpwNHNz", client.requests[0].Headers["Authorization"])
+
+	step := authStep
+	step.Auth = &model.Auth{Type: model.AuthNone}
+	_, err = sc.Run(step, ctx)
+	test.Ok(t, err)
+	test.Equals(t, "Should not authenticate the step", "", client.requests[1].Headers["Authorization"])
+}
+
+func TestAuthReplacesCommandLineAuthorization(t *testing.T) {
+	test.SetupLog()
+	viper.Set("headers", map[string]string{"authorization": "Bearer global"})
+	defer viper.Set("headers", map[string]string{})
+	client := &authClientMock{}
+	sc := controller.NewStepController(client, controller.NewAssertionController())
+	ctx := context.NewContext()
+	ctx.SetAuth(&model.Auth{Type: model.AuthBasic, Basic: &model.Credentials{Username: "john", Password: "p4ss"}})
+
+	_, err := sc.Run(authStep, ctx)
+	test.Ok(t, err)
+	test.Equals(t, "Should send the credentials of the auth", map[string]string{"Authorization": "Basic am9objpwNHNz"},
+		client.requests[0].Headers)
+
+	step := authStep
+	step.Headers = map[string][]string{"Authorization": {"Bearer step"}}
+	viper.Set("headers", map[string]string{})
+	_, err = sc.Run(step, ctx)
+	test.Ok(t, err)
+	test.Equals(t, "Should keep the Authorization header of the step", "Bearer step", client.requests[1].Headers["Authorization"])
+}
+
+// awsClientMock is an API checking the AWS signature of the requests signed with the key AKID/s3cr3t.
+type awsClientMock struct {
+	requests []rest.Request
+}
+
+func (c *awsClientMock) Send(request rest.Request, timeout time.Duration, jar http.CookieJar,
+	tlsConfig *model.TLSConfig) (*rest.Response, *tls.ConnectionState, error) {
+	c.requests = append(c.requests, request)
+	date, err := time.Parse("20060102T150405Z", request.Headers["X-Amz-Date"])
+	if err != nil {
+		return &rest.Response{StatusCode: 403}, nil, nil
+	}
+
+	// the request is signed again without the signature headers
+	unsigned := request
+	unsigned.Headers = map[string]string{}
+	for key, value := range request.Headers {
+		if key != "Authorization" && !strings.HasPrefix(key, "X-Amz-") {
+			unsigned.Headers[key] = value
+		}
+	}
+	req, err := rest.BuildRequestObject(unsigned)
+	if err != nil {
+		return nil, nil, err
+	}
+	credentials := sigv4.Credentials{AccessKeyID: "AKID", SecretAccessKey: "s3cr3t", SessionToken: request.Headers["X-Amz-Security-Token"]}
+	sigv4.Sign(req, request.Body, credentials, "eu-west-1", "execute-api", date)
+	if req.Header.Get("Authorization") != request.Headers["Authorization"] {
+		return &rest.Response{StatusCode: 403}, nil, nil
+	}
+	return &rest.Response{StatusCode: 200}, nil, nil
+}
+
+func TestAuthAWSSigV4(t *testing.T) {
+	test.SetupLog()
+	defer secret.GetRegistry().Reset()
+	client := &awsClientMock{}
+	sc := controller.NewStepController(client, controller.NewAssertionController())
+	ctx := context.NewContext()
+	ctx.Add("aws_secret", "s3cr3t")
+
+	step := authStep
+	step.Method = "POST"
+	step.Body = `{"name":"john"}`
+	step.Auth = &model.Auth{Type: model.AuthAWSSigV4, AWS: &model.AWSSigV4{
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "{{aws_secret}}",
+		SessionToken:    "t0ken",
+		Region:          "eu-west-1",
+		Service:         "execute-api",
+	}}
+	got, err := sc.Run(step, ctx)
+	test.Ok(t, err)
+	test.Equals(t, "Step should be a success", true, got.IsSuccess())
+	test.Equals(t, "Should send the session token", "t0ken", client.requests[0].Headers["X-Amz-Security-Token"])
+	test.Assert(t, strings.HasPrefix(got.Request.Headers["Authorization"], "AWS4-HMAC-SHA256 Credential=AKID/"),
+		"Should record the signature: %s", got.Request.Headers["Authorization"])
+
+	step.Auth.AWS.SecretAccessKey = "wrong"
+	got, err = sc.Run(step, ctx)
+	test.Ok(t, err)
+	test.Equals(t, "Step should be failed with a wrong key", false, got.IsSuccess())
+}
+
+func TestAuthAWSSigV4FromEnv(t *testing.T) {
+	test.SetupLog()
+	defer secret.GetRegistry().Reset()
+	for key, value := range map[string]string{"AWS_ACCESS_KEY_ID": "AKID", "AWS_SECRET_ACCESS_KEY": "s3cr3t", "AWS_REGION": "eu-west-1"} {
+		os.Setenv(key, value)
+		defer os.Unsetenv(key)
+	}
+	client := &awsClientMock{}
+	sc := controller.NewStepController(client, controller.NewAssertionController())
+
+	step := authStep
+	step.Auth = &model.Auth{Type: model.AuthAWSSigV4, AWS: &model.AWSSigV4{Service: "execute-api"}}
+	got, err := sc.Run(step, context.NewContext())
+	test.Ok(t, err)
+	test.Equals(t, "Step should be a success", true, got.IsSuccess())
+
+	os.Unsetenv("AWS_REGION")
+	os.Unsetenv("AWS_DEFAULT_REGION")
+	got, err = sc.Run(step, context.NewContext())
+	test.Ok(t, err)
+	test.Equals(t, "wrong error", "impossible to sign the request: the AWS access key, secret key, region and service are needed", got.Err.Error())
+}
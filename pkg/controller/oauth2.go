@@ -0,0 +1,227 @@
+package controller
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sendgrid/rest"
+	"github.com/thomaspoignant/api-scenario/pkg/context"
+	"github.com/thomaspoignant/api-scenario/pkg/model"
+	"github.com/thomaspoignant/api-scenario/pkg/secret"
+)
+
+// expiryDelta is the time before its expiry when a token is considered as expired,
+// so a token does not expire during the request.
+const expiryDelta = 10 * time.Second
+
+// oauth2Token is the response of the token endpoint.
+type oauth2Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	expiry       time.Time
+}
+
+// valid checks if the token can be used, a token without expires_in never expires.
+func (t *oauth2Token) valid() bool {
+	return t != nil && len(t.AccessToken) > 0 && (t.expiry.IsZero() || time.Now().Add(expiryDelta).Before(t.expiry))
+}
+
+// authorization returns the value of the Authorization header.
+func (t *oauth2Token) authorization() string {
+	tokenType := t.TokenType
+	if len(tokenType) == 0 || strings.EqualFold(tokenType, "bearer") {
+		tokenType = "Bearer"
+	}
+	return tokenType + " " + t.AccessToken
+}
+
+// oauth2TokenCache keeps the tokens shared by all the scenarios using the same OAuth2 configuration.
+// Each configuration has its own lock, so the token endpoint is called once for a configuration
+// without blocking the scenarios using other configurations.
+type oauth2TokenCache struct {
+	mu     sync.Mutex
+	locks  map[string]*sync.Mutex
+	tokens map[string]*oauth2Token
+}
+
+func newOAuth2TokenCache() *oauth2TokenCache {
+	return &oauth2TokenCache{locks: map[string]*sync.Mutex{}, tokens: map[string]*oauth2Token{}}
+}
+
+// lock locks the configuration and returns the function to unlock it.
+func (c *oauth2TokenCache) lock(key string) func() {
+	c.mu.Lock()
+	lock, ok := c.locks[key]
+	if !ok {
+		lock = &sync.Mutex{}
+		c.locks[key] = lock
+	}
+	c.mu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+func (c *oauth2TokenCache) get(key string) *oauth2Token {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tokens[key]
+}
+
+func (c *oauth2TokenCache) set(key string, token *oauth2Token) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tokens[key] = token
+}
+
+// token returns a valid token for the configuration, the token endpoint is called only if there is no valid token.
+// rejected is an access token refused by the API, it is not returned even if it has not expired.
+// The token endpoint is called with the TLS configuration of the scenario.
+// The token is refreshed if the server gave a refresh token, otherwise a new token is requested.
+func (c *oauth2TokenCache) token(client RestClient, config model.OAuth2, rejected string,
+	timeout time.Duration, tlsConfig *model.TLSConfig) (*oauth2Token, error) {
+	key := strings.Join(append([]string{
+		config.GrantType.String(), config.TokenURL, config.ClientID, config.ClientSecret,
+		config.Username, config.Password, fmt.Sprint(config.CredentialsInBody)}, config.Scopes...), "\x00")
+
+	defer c.lock(key)()
+	current := c.get(key)
+	if current.valid() && current.AccessToken != rejected {
+		return current, nil
+	}
+
+	if current != nil && len(current.RefreshToken) > 0 {
+		form := url.Values{"grant_type": {"refresh_token"}, "refresh_token": {current.RefreshToken}}
+		if token, err := requestToken(client, config, form, timeout, tlsConfig); err == nil {
+			if len(token.RefreshToken) == 0 {
+				token.RefreshToken = current.RefreshToken
+			}
+			c.set(key, token)
+			return token, nil
+		}
+	}
+
+	form := url.Values{"grant_type": {config.GrantType.String()}}
+	if config.GrantType == model.PasswordGrant {
+		form.Set("username", config.Username)
+		form.Set("password", config.Password)
+	}
+	token, err := requestToken(client, config, form, timeout, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	c.set(key, token)
+	return token, nil
+}
+
+// requestToken calls the token endpoint with the client credentials.
+func requestToken(client RestClient, config model.OAuth2, form url.Values, timeout time.Duration,
+	tlsConfig *model.TLSConfig) (*oauth2Token, error) {
+	if len(config.Scopes) > 0 {
+		form.Set("scope", strings.Join(config.Scopes, " "))
+	}
+	headers := map[string]string{
+		"Content-Type": "application/x-www-form-urlencoded",
+		"Accept":       "application/json",
+	}
+	if config.CredentialsInBody {
+		form.Set("client_id", config.ClientID)
+		if len(config.ClientSecret) > 0 {
+			form.Set("client_secret", config.ClientSecret)
+		}
+	} else {
+		credentials := url.QueryEscape(config.ClientID) + ":" + url.QueryEscape(config.ClientSecret)
+		headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+	}
+
+	res, _, err := client.Send(rest.Request{
+		Method:  rest.Post,
+		BaseURL: config.TokenURL,
+		Headers: headers,
+		Body:    []byte(form.Encode()),
+	}, timeout, nil, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		var oauthErr struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		if json.Unmarshal([]byte(res.Body), &oauthErr) == nil && len(oauthErr.Error) > 0 {
+			return nil, fmt.Errorf("the token endpoint returned the status %d: %s",
+				res.StatusCode, strings.TrimSpace(oauthErr.Error+" "+oauthErr.Description))
+		}
+		return nil, fmt.Errorf("the token endpoint returned the status %d", res.StatusCode)
+	}
+
+	var token oauth2Token
+	if err := json.Unmarshal([]byte(res.Body), &token); err != nil {
+		return nil, fmt.Errorf("invalid response of the token endpoint: %v", err)
+	}
+	if len(token.AccessToken) == 0 {
+		return nil, fmt.Errorf("the response of the token endpoint has no access_token")
+	}
+	if token.ExpiresIn > 0 {
+		token.expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	secret.GetRegistry().AddValue(token.AccessToken)
+	secret.GetRegistry().AddValue(token.RefreshToken)
+	return &token, nil
+}
+
+// patchOAuth2 replaces the variables in the OAuth2 configuration.
+func patchOAuth2(config model.OAuth2, ctx *context.Context) (model.OAuth2, error) {
+	var errs []string
+	patch := func(s string) string {
+		patched, err := ctx.PatchWithError(s)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+		return patched
+	}
+
+	config.TokenURL = patch(config.TokenURL)
+	config.ClientID = patch(config.ClientID)
+	config.ClientSecret = patch(config.ClientSecret)
+	config.Username = patch(config.Username)
+	config.Password = patch(config.Password)
+	scopes := config.Scopes
+	config.Scopes = nil
+	for _, scope := range scopes {
+		config.Scopes = append(config.Scopes, patch(scope))
+	}
+
+	if len(errs) > 0 {
+		return config, errors.New(strings.Join(errs, ", "))
+	}
+	secret.GetRegistry().AddValue(config.ClientSecret)
+	secret.GetRegistry().AddValue(config.Password)
+	return config, nil
+}
+
+// authorizeOAuth2 adds the Authorization header with an OAuth2 access token to the request.
+// rejected is an access token refused by the API, a new token is used instead.
+// It returns the access token used.
+func (sc *stepControllerImpl) authorizeOAuth2(req *rest.Request, oauth2 model.OAuth2, rejected string,
+	timeout time.Duration, ctx *context.Context) (string, error) {
+	config, err := patchOAuth2(oauth2, ctx)
+	if err != nil {
+		return "", fmt.Errorf("impossible to get the OAuth2 token: %v", err)
+	}
+	token, err := sc.tokens.token(sc.client, config, rejected, timeout, ctx.TLS())
+	if err != nil {
+		return "", fmt.Errorf("impossible to get the OAuth2 token: %v", err)
+	}
+	req.Headers["Authorization"] = token.authorization()
+	return token.AccessToken, nil
+}
@@ -0,0 +1,203 @@
+package controller_test
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sendgrid/rest"
+	"github.com/thomaspoignant/api-scenario/pkg/context"
+	"github.com/thomaspoignant/api-scenario/pkg/controller"
+	"github.com/thomaspoignant/api-scenario/pkg/model"
+	"github.com/thomaspoignant/api-scenario/test"
+)
+
+// oauth2ClientMock is an OAuth2 server issuing the tokens token-1, token-2... and an API accepting only the last token.
+type oauth2ClientMock struct {
+	expiresIn     int
+	tokenStatus   int
+	tokenRequests []rest.Request
+	apiRequests   []rest.Request
+}
+
+func (c *oauth2ClientMock) Send(request rest.Request, timeout time.Duration, jar http.CookieJar,
+	tlsConfig *model.TLSConfig) (*rest.Response, *tls.ConnectionState, error) {
+	if request.BaseURL == "https://auth.test.com/token" {
+		c.tokenRequests = append(c.tokenRequests, request)
+		if c.tokenStatus != 0 {
+			return &rest.Response{StatusCode: c.tokenStatus, Body: `{"error":"invalid_client","error_description":"unknown client"}`}, nil, nil
+		}
+		body := fmt.Sprintf(`{"access_token":"token-%d","token_type":"bearer","expires_in":%d,"refresh_token":"refresh"}`,
+			len(c.tokenRequests), c.expiresIn)
+		return &rest.Response{StatusCode: 200, Body: body}, nil, nil
+	}
+
+	c.apiRequests = append(c.apiRequests, request)
+	if request.Headers["Authorization"] != fmt.Sprintf("Bearer token-%d", len(c.tokenRequests)) {
+		return &rest.Response{StatusCode: 401}, nil, nil
+	}
+	return &rest.Response{StatusCode: 200, Body: `{}`}, nil, nil
+}
+
+func oauth2Context(config model.OAuth2) *context.Context {
+	ctx := context.NewContext()
+	ctx.Add("client_secret", "s3cr3t")
+	config.TokenURL = "https://auth.test.com/token"
+	ctx.SetAuth(&model.Auth{Type: model.AuthOAuth2, OAuth2: &config})
+	return ctx
+}
+
+var oauth2Step = model.Step{
+	StepType: model.RequestStep,
+	URL:      "https://api.test.com/users",
+	Method:   "GET",
+	Assertions: []model.Assertion{
+		{Source: model.ResponseStatus, Comparison: model.EqualNumber, Value: "200"},
+	},
+}
+
+func TestOAuth2ClientCredentials(t *testing.T) {
+	test.SetupLog()
+	client := &oauth2ClientMock{expiresIn: 3600}
+	sc := controller.NewStepController(client, controller.NewAssertionController())
+	ctx := oauth2Context(model.OAuth2{ClientID: "app", ClientSecret: "{{client_secret}}", Scopes: []string{"read", "write"}})
+
+	for i := 0; i < 2; i++ {
+		got, err := sc.Run(oauth2Step, ctx)
+		test.Ok(t, err)
+		test.Equals(t, "Step should be a success", true, got.IsSuccess())
+		test.Equals(t, "Should send the access token", "Bearer token-1", got.Request.Headers["Authorization"])
+	}
+
+	test.Equals(t, "Should request the token once", 1, len(client.tokenRequests))
+	test.Equals(t, "Should send the client credentials in the header", "Basic YXBwOnMzY3IzdA==", client.tokenRequests[0].Headers["Authorization"])
+	test.Equals(t, "Should request the scopes", "grant_type=client_credentials&scope=read+write", string(client.tokenRequests[0].Body))
+}
+
+func TestOAuth2PasswordGrant(t *testing.T) {
+	test.SetupLog()
+	client := &oauth2ClientMock{}
+	sc := controller.NewStepController(client, controller.NewAssertionController())
+	ctx := oauth2Context(model.OAuth2{
+		GrantType:         model.PasswordGrant,
+		ClientID:          "app",
+		Username:          "john",
+		Password:          "p4ss",
+		CredentialsInBody: true,
+	})
+
+	got, err := sc.Run(oauth2Step, ctx)
+	test.Ok(t, err)
+	test.Equals(t, "Step should be a success", true, got.IsSuccess())
+	test.Equals(t, "Should send the credentials in the body",
+		"client_id=app&grant_type=password&password=p4ss&username=john", string(client.tokenRequests[0].Body))
+	test.Equals(t, "Should not send an Authorization header", "", client.tokenRequests[0].Headers["Authorization"])
+}
+
+func TestOAuth2RefreshWhenExpired(t *testing.T) {
+	test.SetupLog()
+	// the token expires before the expiry delta, it is refreshed before each request
+	client := &oauth2ClientMock{expiresIn: 5}
+	sc := controller.NewStepController(client, controller.NewAssertionController())
+	ctx := oauth2Context(model.OAuth2{ClientID: "app"})
+
+	for i := 0; i < 2; i++ {
+		got, err := sc.Run(oauth2Step, ctx)
+		test.Ok(t, err)
+		test.Equals(t, "Step should be a success", true, got.IsSuccess())
+	}
+	test.Equals(t, "Should request a token for each request", 2, len(client.tokenRequests))
+	test.Equals(t, "Should use the refresh token", "grant_type=refresh_token&refresh_token=refresh", string(client.tokenRequests[1].Body))
+}
+
+func TestOAuth2RefreshWhenRefused(t *testing.T) {
+	test.SetupLog()
+	client := &oauth2ClientMock{expiresIn: 3600}
+	sc := controller.NewStepController(client, controller.NewAssertionController())
+	ctx := oauth2Context(model.OAuth2{ClientID: "app"})
+
+	_, err := sc.Run(oauth2Step, ctx)
+	test.Ok(t, err)
+
+	// the server revokes the token by issuing a new one
+	client.tokenRequests = append(client.tokenRequests, rest.Request{})
+	got, err := sc.Run(oauth2Step, ctx)
+	test.Ok(t, err)
+	test.Equals(t, "Step should be a success", true, got.IsSuccess())
+	test.Equals(t, "Should send the request again", 3, len(client.apiRequests))
+	test.Equals(t, "Should record the last request", "Bearer token-3", got.Request.Headers["Authorization"])
+}
+
+func TestOAuth2TokenError(t *testing.T) {
+	test.SetupLog()
+	client := &oauth2ClientMock{tokenStatus: 400}
+	sc := controller.NewStepController(client, controller.NewAssertionController())
+	ctx := oauth2Context(model.OAuth2{ClientID: "app"})
+
+	got, err := sc.Run(oauth2Step, ctx)
+	test.Ok(t, err)
+	test.Equals(t, "Step should be failed", false, got.IsSuccess())
+	test.Equals(t, "Should not call the API", 0, len(client.apiRequests))
+	test.Equals(t, "wrong error",
+		"impossible to get the OAuth2 token: the token endpoint returned the status 400: invalid_client unknown client", got.Err.Error())
+}
+
+func TestOAuth2ExplicitHeader(t *testing.T) {
+	test.SetupLog()
+	client := &oauth2ClientMock{}
+	sc := controller.NewStepController(client, controller.NewAssertionController())
+	ctx := oauth2Context(model.OAuth2{ClientID: "app"})
+
+	step := oauth2Step
+	step.Headers = map[string][]string{"Authorization": {"Bearer other"}}
+	got, err := sc.Run(step, ctx)
+	test.Ok(t, err)
+	test.Equals(t, "Should keep the header of the step", "Bearer other", got.Request.Headers["Authorization"])
+	test.Equals(t, "Should not request a token", 0, len(client.tokenRequests))
+}
+
+// slowTokenClientMock is an OAuth2 server answering to the client "slow" only after a token is issued to another client.
+type slowTokenClientMock struct {
+	slowRequested chan struct{}
+	issued        chan struct{}
+}
+
+func (c *slowTokenClientMock) Send(request rest.Request, timeout time.Duration, jar http.CookieJar,
+	tlsConfig *model.TLSConfig) (*rest.Response, *tls.ConnectionState, error) {
+	if request.BaseURL == "https://auth.test.com/token" {
+		if request.Headers["Authorization"] == "Basic c2xvdzo=" {
+			close(c.slowRequested)
+			<-c.issued
+		} else {
+			defer close(c.issued)
+		}
+		return &rest.Response{StatusCode: 200, Body: `{"access_token":"token","token_type":"bearer"}`}, nil, nil
+	}
+	return &rest.Response{StatusCode: 200, Body: `{}`}, nil, nil
+}
+
+func TestOAuth2ConfigurationsNotBlocked(t *testing.T) {
+	test.SetupLog()
+	client := &slowTokenClientMock{slowRequested: make(chan struct{}), issued: make(chan struct{})}
+	sc := controller.NewStepController(client, controller.NewAssertionController())
+
+	done := make(chan bool, 2)
+	run := func(clientID string) {
+		got, err := sc.Run(oauth2Step, oauth2Context(model.OAuth2{ClientID: clientID}))
+		done <- err == nil && got.IsSuccess()
+	}
+	go run("slow")
+	<-client.slowRequested
+	go run("fast")
+
+	for i := 0; i < 2; i++ {
+		select {
+		case success := <-done:
+			test.Equals(t, "Step should be a success", true, success)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the token request of a configuration should not block the other configurations")
+		}
+	}
+}
@@ -1,18 +1,118 @@
 package controller
 
-import "github.com/sendgrid/rest"
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sendgrid/rest"
+	"github.com/thomaspoignant/api-scenario/pkg/model"
+)
 
 type RestClient interface {
-	Send(request rest.Request) (*rest.Response, error)
+	Send(request rest.Request, timeout time.Duration, jar http.CookieJar,
+		tlsConfig *model.TLSConfig) (*rest.Response, *tls.ConnectionState, error)
 }
 
 func NewRestClient() RestClient {
-	return &RestClientImpl{}
+	return &RestClientImpl{
+		httpClient: http.DefaultClient,
+		transports: map[model.TLSConfig]*http.Transport{},
+	}
 }
 
 type RestClientImpl struct {
+	httpClient *http.Client
+
+	// transports are the transports created for each TLS configuration, so the connections are reused.
+	mu         sync.Mutex
+	transports map[model.TLSConfig]*http.Transport
+}
+
+// Send is calling the API, if timeout is greater than 0 the request is cancelled after this duration.
+// If jar is not nil, its cookies are sent with the request and it receives the cookies of the responses.
+// If tlsConfig is not nil, the request uses a transport with this TLS configuration.
+// It returns the state of the TLS connection, nil for plain HTTP.
+func (c *RestClientImpl) Send(request rest.Request, timeout time.Duration, jar http.CookieJar,
+	tlsConfig *model.TLSConfig) (*rest.Response, *tls.ConnectionState, error) {
+	req, err := rest.BuildRequestObject(request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if timeout > 0 {
+		ctx, cancel := context.WithTimeout(req.Context(), timeout)
+		defer cancel()
+		req = req.WithContext(ctx)
+	}
+
+	httpClient := *c.httpClient
+	httpClient.Jar = jar
+	if tlsConfig != nil {
+		if httpClient.Transport, err = c.transport(*tlsConfig); err != nil {
+			return nil, nil, err
+		}
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	state := res.TLS
+	response, err := rest.BuildResponse(res)
+	return response, state, err
 }
 
-func (*RestClientImpl) Send(request rest.Request) (*rest.Response, error) {
-	return rest.Send(request)
+// transport returns the transport for the TLS configuration, it is created at the first call.
+func (c *RestClientImpl) transport(config model.TLSConfig) (*http.Transport, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if transport, ok := c.transports[config]; ok {
+		return transport, nil
+	}
+
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	c.transports[config] = transport
+	return transport, nil
+}
+
+// newTLSConfig creates the TLS configuration, the CA certificates are trusted in addition to the system ones.
+func newTLSConfig(config model.TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         config.ServerName,
+		InsecureSkipVerify: config.Insecure,
+	}
+
+	if len(config.CACert) > 0 {
+		pem, err := ioutil.ReadFile(config.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("impossible to read the CA certificates: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no CA certificate found in the file %s", config.CACert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if len(config.ClientCert) > 0 || len(config.ClientKey) > 0 {
+		cert, err := tls.LoadX509KeyPair(config.ClientCert, config.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("impossible to load the client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
 }
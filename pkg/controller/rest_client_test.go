@@ -0,0 +1,146 @@
+package controller_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sendgrid/rest"
+	"github.com/thomaspoignant/api-scenario/pkg/controller"
+	"github.com/thomaspoignant/api-scenario/pkg/model"
+	"github.com/thomaspoignant/api-scenario/test"
+)
+
+func newSlowServer(delay time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"hello":"world"}`)
+	}))
+}
+
+func TestRestClientSend(t *testing.T) {
+	server := newSlowServer(0)
+	defer server.Close()
+
+	client := controller.NewRestClient()
+	got, _, err := client.Send(rest.Request{Method: rest.Get, BaseURL: server.URL}, time.Second, nil, nil)
+	test.Ok(t, err)
+	test.Equals(t, "Should have status 200", 200, got.StatusCode)
+	test.Equals(t, "Should have the body", `{"hello":"world"}`, got.Body)
+}
+
+func TestRestClientTimeout(t *testing.T) {
+	server := newSlowServer(2 * time.Second)
+	defer server.Close()
+
+	client := controller.NewRestClient()
+	start := time.Now()
+	_, _, err := client.Send(rest.Request{Method: rest.Get, BaseURL: server.URL}, 100*time.Millisecond, nil, nil)
+	test.Ko(t, err)
+	netErr, ok := err.(net.Error)
+	test.Assert(t, ok && netErr.Timeout(), "Error should be a timeout: %v", err)
+	test.Assert(t, time.Since(start) < time.Second, "Request should have been cancelled by the timeout")
+}
+
+// writeServerCA saves the certificate of the test server in a file, to use it as a CA bundle.
+func writeServerCA(t *testing.T, server *httptest.Server) string {
+	file, err := ioutil.TempFile("", "ca-*.pem")
+	test.Ok(t, err)
+	defer file.Close()
+	err = pem.Encode(file, &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	test.Ok(t, err)
+	return file.Name()
+}
+
+func TestRestClientUnknownAuthority(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := controller.NewRestClient()
+	_, _, err := client.Send(rest.Request{Method: rest.Get, BaseURL: server.URL}, time.Second, nil, nil)
+	test.Ko(t, err)
+}
+
+func TestRestClientCACert(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	caCert := writeServerCA(t, server)
+	defer os.Remove(caCert)
+
+	client := controller.NewRestClient()
+	got, state, err := client.Send(rest.Request{Method: rest.Get, BaseURL: server.URL}, time.Second, nil,
+		&model.TLSConfig{CACert: caCert})
+	test.Ok(t, err)
+	test.Equals(t, "Should have status 200", 200, got.StatusCode)
+	test.Assert(t, state != nil && state.HandshakeComplete, "Should return the TLS state")
+
+	// the certificate of the test server is valid for example.com
+	_, _, err = client.Send(rest.Request{Method: rest.Get, BaseURL: server.URL}, time.Second, nil,
+		&model.TLSConfig{CACert: caCert, ServerName: "example.com"})
+	test.Ok(t, err)
+	_, _, err = client.Send(rest.Request{Method: rest.Get, BaseURL: server.URL}, time.Second, nil,
+		&model.TLSConfig{CACert: caCert, ServerName: "unknown.com"})
+	test.Ko(t, err)
+}
+
+func TestRestClientInsecure(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := controller.NewRestClient()
+	got, _, err := client.Send(rest.Request{Method: rest.Get, BaseURL: server.URL}, time.Second, nil,
+		&model.TLSConfig{Insecure: true})
+	test.Ok(t, err)
+	test.Equals(t, "Should have status 200", 200, got.StatusCode)
+}
+
+func TestRestClientClientCertificate(t *testing.T) {
+	clientCert, err := ioutil.ReadFile("../../testdata/tls/client.pem")
+	test.Ok(t, err)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM(clientCert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	client := controller.NewRestClient()
+	_, _, err = client.Send(rest.Request{Method: rest.Get, BaseURL: server.URL}, time.Second, nil,
+		&model.TLSConfig{Insecure: true})
+	test.Ko(t, err)
+
+	got, _, err := client.Send(rest.Request{Method: rest.Get, BaseURL: server.URL}, time.Second, nil,
+		&model.TLSConfig{
+			ClientCert: "../../testdata/tls/client.pem",
+			ClientKey:  "../../testdata/tls/client-key.pem",
+			Insecure:   true,
+		})
+	test.Ok(t, err)
+	test.Equals(t, "Should send the client certificate", "api-scenario-client", got.Body)
+}
+
+func TestRestClientInvalidTLSConfig(t *testing.T) {
+	client := controller.NewRestClient()
+	_, _, err := client.Send(rest.Request{Method: rest.Get, BaseURL: "https://test.com"}, time.Second, nil,
+		&model.TLSConfig{CACert: "../../testdata/tls/unknown.pem"})
+	test.Ko(t, err)
+
+	_, _, err = client.Send(rest.Request{Method: rest.Get, BaseURL: "https://test.com"}, time.Second, nil,
+		&model.TLSConfig{CACert: "../../testdata/tls/client-key.pem"})
+	test.Equals(t, "Should not find a certificate", "no CA certificate found in the file ../../testdata/tls/client-key.pem", err.Error())
+}
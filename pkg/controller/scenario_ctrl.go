@@ -1,12 +1,12 @@
 package controller
 
 import (
-	"github.com/sirupsen/logrus"
+	"github.com/thomaspoignant/api-scenario/pkg/context"
 	"github.com/thomaspoignant/api-scenario/pkg/model"
 )
 
 type ScenarioController interface {
-	Run(scenario model.Scenario) model.ScenarioResult
+	Run(scenario model.Scenario, ctx *context.Context) model.ScenarioResult
 }
 
 func NewScenarioController(stepCtrl StepController) ScenarioController {
@@ -19,7 +19,7 @@ type scenarioControllerImpl struct {
 	stepController StepController
 }
 
-func (s *scenarioControllerImpl) Run(scenario model.Scenario) model.ScenarioResult {
+func (s *scenarioControllerImpl) Run(scenario model.Scenario, ctx *context.Context) model.ScenarioResult {
 	result := model.ScenarioResult{
 		Name:        scenario.Name,
 		Description: scenario.Description,
@@ -27,19 +27,38 @@ func (s *scenarioControllerImpl) Run(scenario model.Scenario) model.ScenarioResu
 		StepResults: []model.ResultStep{},
 	}
 
-	logrus.Infof("Running api-scenario: %s (%s)", scenario.Name, scenario.Version)
-	logrus.Infof("%s\n", scenario.Description)
+	// Add default values of the scenario variables
+	for key, value := range scenario.Variables {
+		if _, exists := ctx.Get(key); !exists {
+			ctx.Add(key, value)
+		}
+	}
+
+	// The authentication of the scenario replaces the one from the command line
+	if scenario.Auth != nil {
+		ctx.SetAuth(scenario.Auth)
+	}
+
+	// The TLS settings of the scenario override the ones from the command line
+	if scenario.TLS != nil {
+		ctx.SetTLS(ctx.TLS().Override(scenario.TLS))
+	}
+
+	logger := ctx.Logger()
+	logger.Infof("Running api-scenario: %s (%s)", scenario.Name, scenario.Version)
+	logger.Infof("%s\n", scenario.Description)
 
 	for _, step := range scenario.Steps {
 		if step.Skipped {
-			logrus.Error("step is skipped")
+			logger.Error("step is skipped")
 			continue
 		}
 
-		stepRes, err := s.stepController.Run(step)
+		stepRes, err := s.stepController.Run(step, ctx)
 		if err != nil {
-			logrus.Errorf("impossible to execute the step: %v\n%v", err, step)
-			continue
+			// the step is kept as a failed step, so the error is in the results and the output files
+			logger.Errorf("impossible to execute the step: %v\n%v", err, step)
+			stepRes = model.ResultStep{StepType: step.StepType, Err: err}
 		}
 		result.StepResults = append(result.StepResults, stepRes)
 	}
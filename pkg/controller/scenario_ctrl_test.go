@@ -2,6 +2,7 @@ package controller_test
 
 import (
 	"errors"
+	"github.com/thomaspoignant/api-scenario/pkg/context"
 	"github.com/thomaspoignant/api-scenario/pkg/controller"
 	"github.com/thomaspoignant/api-scenario/pkg/model"
 	"github.com/thomaspoignant/api-scenario/test"
@@ -13,7 +14,7 @@ type MockStepController struct {
 	wantedResponse int
 }
 
-func (m MockStepController) Run(step model.Step) (model.ResultStep, error) {
+func (m MockStepController) Run(step model.Step, ctx *context.Context) (model.ResultStep, error) {
 
 	switch m.wantedResponse {
 	case 1:
@@ -50,7 +51,7 @@ func TestScenarioError(t *testing.T) {
 	ctrl := controller.NewScenarioController(MockStepController{2})
 	var got model.ScenarioResult
 	output := test.CaptureOutput(func() {
-		got = ctrl.Run(scenario)
+		got = ctrl.Run(scenario, context.GetContext())
 	})
 
 	test.Equals(t, "Name should be the same", scenario.Name, got.Name)
@@ -78,7 +79,7 @@ func TestScenarioSuccess(t *testing.T) {
 	ctrl := controller.NewScenarioController(MockStepController{1})
 	var got model.ScenarioResult
 	output := test.CaptureOutput(func() {
-		got = ctrl.Run(scenario)
+		got = ctrl.Run(scenario, context.GetContext())
 	})
 
 	test.Equals(t, "Name should be the same", scenario.Name, got.Name)
@@ -106,15 +107,40 @@ func TestScenarioImpossible(t *testing.T) {
 	ctrl := controller.NewScenarioController(MockStepController{3})
 	var got model.ScenarioResult
 	output := test.CaptureOutput(func() {
-		got = ctrl.Run(scenario)
+		got = ctrl.Run(scenario, context.GetContext())
 	})
 
 	test.Equals(t, "Name should be the same", scenario.Name, got.Name)
 	test.Equals(t, "Description should be the same", scenario.Description, got.Description)
 	test.Equals(t, "Version should be the same", scenario.Version, got.Version)
-	test.Equals(t, "There is no error scenario should be a success", true, got.IsSuccess())
-	test.Equals(t, "Should not have step results cause it is ignored", 0, len(got.StepResults))
+	test.Equals(t, "The step is in error scenario should be a failure", false, got.IsSuccess())
+	test.Equals(t, "Should have the failed step in the results", 1, len(got.StepResults))
+	test.Equals(t, "Should record the error of the step", "RequestXXX is an invalid step_type", got.StepResults[0].Err.Error())
 
 	wantedPrefix := "Running api-scenario: Test Scenario (1.0)\nThis is a test scenario\n\nimpossible to execute the step: RequestXXX is an invalid step_type"
 	test.Assert(t, strings.HasPrefix(output, wantedPrefix), "Output should starts with %v and got %v", wantedPrefix, output)
 }
+
+func TestScenarioDefaultVariables(t *testing.T) {
+	test.SetupLog()
+	scenario := model.Scenario{
+		Name: "Test Scenario",
+		Steps: []model.Step{
+			{StepType: model.RequestStep, URL: "first"},
+		},
+		Variables: map[string]string{
+			"step":    "default",
+			"baseUrl": "http://localhost",
+		},
+	}
+
+	ctx := context.NewContext()
+	ctx.Add("baseUrl", "http://test.com")
+	ctrl := controller.NewScenarioController(MockVariableStepController{})
+	output := test.CaptureOutput(func() {
+		ctrl.Run(scenario, ctx)
+	})
+
+	test.Assert(t, strings.Contains(output, "first after default\n"), "Default value should be used: %s", output)
+	test.Equals(t, "Existing variable should not be overridden", "http://test.com", ctx.Patch("{{baseUrl}}"))
+}
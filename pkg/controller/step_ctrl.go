@@ -1,13 +1,25 @@
 package controller
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"github.com/clbanning/mxj"
 	"github.com/jmoiron/jsonq"
 	"github.com/thomaspoignant/api-scenario/pkg/util"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/textproto"
 	"net/url"
+	"path/filepath"
 	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sendgrid/rest"
@@ -15,33 +27,36 @@ import (
 	"github.com/spf13/viper"
 	"github.com/thomaspoignant/api-scenario/pkg/context"
 	"github.com/thomaspoignant/api-scenario/pkg/model"
+	"github.com/thomaspoignant/api-scenario/pkg/secret"
 )
 
 type StepController interface {
-	Run(step model.Step) (model.ResultStep, error)
+	Run(step model.Step, ctx *context.Context) (model.ResultStep, error)
 }
 
 type stepControllerImpl struct {
 	client        RestClient
 	assertionCtrl AssertionController
+	tokens        *oauth2TokenCache
 }
 
 func NewStepController(client RestClient, assertionCtrl AssertionController) StepController {
 	return &stepControllerImpl{
 		client:        client,
 		assertionCtrl: assertionCtrl,
+		tokens:        newOAuth2TokenCache(),
 	}
 }
 
 // Run is running the step and assert it.
-func (sc *stepControllerImpl) Run(step model.Step) (model.ResultStep, error) {
+func (sc *stepControllerImpl) Run(step model.Step, ctx *context.Context) (model.ResultStep, error) {
 
 	switch step.StepType {
 	case model.Pause:
-		return sc.pause(step.Duration)
+		return sc.pause(step.Duration, ctx)
 
 	case model.RequestStep:
-		return sc.request(step)
+		return sc.request(step, ctx)
 
 	default:
 		// Cannot happen, all value tested
@@ -50,10 +65,10 @@ func (sc *stepControllerImpl) Run(step model.Step) (model.ResultStep, error) {
 }
 
 // pause is stopping the thread during numberOfSecond seconds.
-func (sc *stepControllerImpl) pause(numberOfSecond int) (model.ResultStep, error) {
+func (sc *stepControllerImpl) pause(numberOfSecond int, ctx *context.Context) (model.ResultStep, error) {
 	start := time.Now()
-	logrus.Info("------------------------")
-	logrus.Infof("Waiting for %ds", numberOfSecond)
+	ctx.Logger().Info("------------------------")
+	ctx.Logger().Infof("Waiting for %ds", numberOfSecond)
 	// compute pause time and wait
 	duration := time.Duration(numberOfSecond) * time.Second
 	time.Sleep(duration)
@@ -66,10 +81,55 @@ func (sc *stepControllerImpl) pause(numberOfSecond int) (model.ResultStep, error
 }
 
 // request is calling a Rest HTTP endpoint and assert the response.
-func (sc *stepControllerImpl) request(step model.Step) (model.ResultStep, error) {
-	// convert step to api req
+// If the step has a retry block, the request is sent again until the assertions pass or there is no attempt left.
+func (sc *stepControllerImpl) request(step model.Step, ctx *context.Context) (model.ResultStep, error) {
+	start := time.Now()
+	if step.Cookies == model.CookiesClear {
+		ctx.ClearCookies()
+	}
+	result, err := sc.sendRequest(step, ctx)
+	if err != nil {
+		return result, err
+	}
+
+	if step.Retry != nil && step.Retry.MaxAttempts > 1 {
+		attempts := []model.ResultStep{result}
+		interval := time.Duration(step.Retry.Interval) * time.Second
+		for attempt := 2; attempt <= step.Retry.MaxAttempts && !isRetryConditionMet(step, result); attempt++ {
+			ctx.Logger().Infof("Attempt %d/%d failed, retrying in %v", attempt-1, step.Retry.MaxAttempts, interval)
+			time.Sleep(interval)
+			interval = nextRetryInterval(interval, step.Retry.Backoff)
+
+			result, err = sc.sendRequest(step, ctx)
+			if err != nil {
+				return result, err
+			}
+			attempts = append(attempts, result)
+		}
+		result.Attempts = attempts
+		result.StepTime = time.Since(start)
+	}
+
+	if result.Err != nil {
+		return result, nil
+	}
+
+	// Add variables to context
+	result.VariablesCreated = attachVariablesToContext(result.Response, step.Variables, step.Namespaces, ctx)
 
-	req, variables, err := convertAndPatchToHttpRequest(step)
+	if len(result.VariablesCreated) > 0 {
+		ctx.Logger().Info("Variables  created:")
+		for _, currentVar := range result.VariablesCreated {
+			currentVar.Print(ctx.Logger())
+		}
+	}
+	return result, nil
+}
+
+// sendRequest is doing one call to the Rest HTTP endpoint and assert the response.
+func (sc *stepControllerImpl) sendRequest(step model.Step, ctx *context.Context) (model.ResultStep, error) {
+	// convert step to api req
+	req, variables, err := convertAndPatchToHttpRequest(step, ctx)
 	if err != nil {
 		return model.ResultStep{}, fmt.Errorf("impossible to convert the request [%s]", err.Error())
 	}
@@ -77,64 +137,133 @@ func (sc *stepControllerImpl) request(step model.Step) (model.ResultStep, error)
 	// init the result
 	result := model.ResultStep{
 		StepType: model.RequestStep,
-		Request:  req,
 	}
 
 	// apply variable on the request
 	result.VariablesApplied = variables
 
+	// add the credentials of the step or of the scenario
+	timeout := requestTimeout(step)
+	auth := requestAuth(step, ctx)
+	accessToken, err := sc.authenticate(&req, auth, step.Headers, timeout, ctx)
+	// the request is recorded as sent, the credentials are masked in the outputs
+	result.Request = req
+	if err != nil {
+		result.Err = err
+		ctx.Logger().Errorf("X\t%s", secret.GetRegistry().Mask(err.Error()))
+		return result, nil
+	}
+
+	// the credentials sent in the headers should not be displayed
+	for key, value := range req.Headers {
+		secret.GetRegistry().AddHeader(key, value)
+	}
+
 	// Display request
-	printRestRequest(req, result.VariablesApplied)
+	printRestRequest(req, result.VariablesApplied, ctx.Logger())
 
 	// call the API
 	start := time.Now()
-	res, err := sc.client.Send(req)
+	var jar http.CookieJar
+	if step.Cookies != model.CookiesDisabled {
+		jar = ctx.CookieJar()
+	}
+	res, tlsState, err := sc.client.Send(req, timeout, jar, ctx.TLS())
+
+	// the credentials were refused, the request is sent again once with new credentials
+	if err == nil {
+		var retry bool
+		if retry, err = sc.reauthenticate(&req, auth, res, accessToken, timeout, ctx); retry {
+			secret.GetRegistry().AddHeader("Authorization", req.Headers["Authorization"])
+			result.Request = req
+			start = time.Now()
+			res, tlsState, err = sc.client.Send(req, timeout, jar, ctx.TLS())
+		}
+	}
 	elapsed := time.Since(start)
 	result.StepTime = elapsed
 	if err != nil {
-		return result, err
+		// The step is in error, we keep the result to have it as a failed step.
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			err = fmt.Errorf("the request timed out after %v", timeout)
+		}
+		result.Err = err
+		ctx.Logger().Errorf("X\t%s", secret.GetRegistry().Mask(err.Error()))
+		return result, nil
 	}
-	logrus.Infof("Time elapsed: %v", elapsed)
+	ctx.Logger().Infof("Time elapsed: %v", elapsed)
 
 	// Create a response
-	response, err := model.NewResponse(*res, elapsed)
+	response, err := model.NewResponse(*res, tlsState, elapsed)
 	if err != nil {
 		return result, err
 	}
 	result.Response = response
 
-	// Check the assertions
-	result.Assertions = sc.assertResponse(response, step.Assertions)
+	// Check the assertions, the "until" assertions of the retry block are checked first.
+	assertions := step.Assertions
+	if step.Retry != nil && len(step.Retry.Until) > 0 {
+		assertions = append(append([]model.Assertion{}, step.Retry.Until...), step.Assertions...)
+	}
+	result.Assertions = sc.assertResponse(response, assertions, step.Namespaces, ctx)
+	return result, nil
+}
 
-	// Add variables to context
-	result.VariablesCreated = attachVariablesToContext(response, step.Variables)
+// isRetryConditionMet checks if we can stop to retry the request.
+// If the retry block has "until" assertions only these ones are checked, otherwise all the assertions should pass.
+func isRetryConditionMet(step model.Step, result model.ResultStep) bool {
+	if result.Err != nil {
+		return false
+	}
 
-	if len(result.VariablesCreated) > 0 {
-		logrus.Info("Variables  created:")
-		for _, currentVar := range result.VariablesCreated {
-			currentVar.Print()
+	assertions := result.Assertions
+	if len(step.Retry.Until) > 0 {
+		assertions = assertions[:len(step.Retry.Until)]
+	}
+	for _, assertion := range assertions {
+		if !assertion.Success {
+			return false
 		}
 	}
-	return result, nil
+	return true
+}
+
+// nextRetryInterval computes the interval before the next attempt.
+func nextRetryInterval(interval time.Duration, backoff float64) time.Duration {
+	if backoff <= 1 {
+		return interval
+	}
+	return time.Duration(float64(interval) * backoff)
+}
+
+// requestTimeout returns the timeout of the step or the default timeout if the step has none.
+func requestTimeout(step model.Step) time.Duration {
+	if step.Timeout > 0 {
+		return time.Duration(step.Timeout) * time.Second
+	}
+	return time.Duration(viper.GetInt("timeout")) * time.Second
 }
 
 // assertResponse assert the response of a REST Call.
-func (sc *stepControllerImpl) assertResponse(response model.Response, assertions []model.Assertion) []model.ResultAssertion {
+func (sc *stepControllerImpl) assertResponse(response model.Response, assertions []model.Assertion,
+	namespaces map[string]string, ctx *context.Context) []model.ResultAssertion {
 	if len(assertions) > 0 {
-		logrus.Info("Assertions:")
+		ctx.Logger().Info("Assertions:")
 	}
 
 	var result []model.ResultAssertion
 	for _, assertion := range assertions {
-		assertionResult := sc.assertionCtrl.Assert(assertion, response)
+		assertionResult := sc.assertionCtrl.Assert(assertion, response, namespaces, ctx)
 		result = append(result, assertionResult)
-		assertionResult.Print()
+		assertionResult.Print(ctx.Logger())
 	}
 	return result
 }
 
 // attachVariablesToContext extract variable from the response and add it to the context.
-func attachVariablesToContext(response model.Response, vars []model.Variable) []model.ResultVariable {
+// namespaces are the XML namespaces usable in the XPath expressions.
+func attachVariablesToContext(response model.Response, vars []model.Variable, namespaces map[string]string,
+	ctx *context.Context) []model.ResultVariable {
 	var result []model.ResultVariable
 
 	for _, variable := range vars {
@@ -145,50 +274,133 @@ func attachVariablesToContext(response model.Response, vars []model.Variable) []
 		switch variable.Source {
 		case model.ResponseTime:
 			value := strconv.FormatInt(int64(response.TimeElapsed.Round(time.Millisecond)/time.Millisecond), 10)
-			context.GetContext().Add(variable.Name, value)
+			ctx.Add(variable.Name, value)
 			result = append(result, model.ResultVariable{Key: variable.Name, NewValue: value, Type: model.Created})
 
 		case model.ResponseStatus:
 			value := fmt.Sprintf("%v", response.StatusCode)
-			context.GetContext().Add(variable.Name, value)
+			ctx.Add(variable.Name, value)
 			result = append(result, model.ResultVariable{Key: variable.Name, NewValue: value, Type: model.Created})
 
 		case model.ResponseHeader:
 			header := response.Header[variable.Property]
 			if len(header) > 0 {
 				// TODO: Works fine if we have only one value for the header
-				context.GetContext().Add(variable.Name, header[0])
+				if len(variable.Regex) > 0 {
+					result = append(result, attachVariableFromRegex(variable, header[0], ctx))
+					continue
+				}
+				ctx.Add(variable.Name, header[0])
 				result = append(result, model.ResultVariable{Key: variable.Name, NewValue: header[0], Type: model.Created})
 			}
 
 		case model.ResponseText:
-			context.GetContext().Add(variable.Name, response.Body)
+			if len(variable.Regex) > 0 {
+				result = append(result, attachVariableFromRegex(variable, response.Body, ctx))
+				continue
+			}
+			ctx.Add(variable.Name, response.Body)
 			result = append(result, model.ResultVariable{Key: variable.Name, NewValue: response.Body, Type: model.Created})
 
 		case model.ResponseJson:
-			result = append(result, attachVariablesFromResponseJson(variable, response))
+			result = append(result, attachVariablesFromResponseJson(variable, response, ctx))
 
 		case model.ResponseXml:
-			result = append(result, attachVariablesFromResponseXml(variable, response))
+			if isXPath(variable.Property) {
+				result = append(result, attachVariablesFromXPath(variable, response, namespaces, ctx))
+				continue
+			}
+			result = append(result, attachVariablesFromResponseXml(variable, response, ctx))
+
+		case model.ResponseHtml:
+			result = append(result, attachVariablesFromResponseHtml(variable, response, ctx))
+
+		case model.ResponseCookie:
+			value, found := cookieValue(response.Cookies, variable.Property)
+			if !found {
+				result = append(result, model.ResultVariable{Key: variable.Name, Err: fmt.Errorf("no cookie found for %q", variable.Property), Type: model.Created})
+				continue
+			}
+			result = append(result, attachVariableValue(variable, value, ctx))
+
+		case model.ResponseTLS:
+			value, found, err := tlsValue(response.TLS, variable.Property, time.Now())
+			if err == nil && !found {
+				err = errors.New(tlsNotFoundMessage(response.TLS))
+			}
+			if err != nil {
+				result = append(result, model.ResultVariable{Key: variable.Name, Err: err, Type: model.Created})
+				continue
+			}
+			result = append(result, attachVariableValue(variable, value, ctx))
 		}
 	}
 	return result
 }
 
+// attachVariableFromRegex extract a group of the regex of the variable from the text and add it to the context.
+func attachVariableFromRegex(variable model.Variable, text string, ctx *context.Context) model.ResultVariable {
+	regex, err := regexp.Compile(variable.Regex)
+	if err != nil {
+		return model.ResultVariable{Key: variable.Name, Err: fmt.Errorf("invalid regex %q: %v", variable.Regex, err), Type: model.Created}
+	}
+
+	groupIndex, err := regexGroupIndex(regex, variable.Group)
+	if err != nil {
+		return model.ResultVariable{Key: variable.Name, Err: err, Type: model.Created}
+	}
+
+	matches := regex.FindStringSubmatch(text)
+	if matches == nil {
+		return model.ResultVariable{Key: variable.Name, Err: fmt.Errorf("the regex %q did not match", variable.Regex), Type: model.Created}
+	}
+
+	ctx.Add(variable.Name, matches[groupIndex])
+	return model.ResultVariable{Key: variable.Name, NewValue: matches[groupIndex], Type: model.Created}
+}
+
+// regexGroupIndex returns the index of a group (name or number) in the regex,
+// without group the first one is used if there is one, else the whole match.
+func regexGroupIndex(regex *regexp.Regexp, group string) (int, error) {
+	if len(group) == 0 {
+		if regex.NumSubexp() > 0 {
+			return 1, nil
+		}
+		return 0, nil
+	}
+
+	if index, err := strconv.Atoi(group); err == nil {
+		if index < 0 || index > regex.NumSubexp() {
+			return 0, fmt.Errorf("the regex %q has no group %d", regex.String(), index)
+		}
+		return index, nil
+	}
+
+	for index, name := range regex.SubexpNames() {
+		if name == group {
+			return index, nil
+		}
+	}
+	return 0, fmt.Errorf("the regex %q has no group named %q", regex.String(), group)
+}
+
 // attachVariablesFromResponseJson extract variable from the JSON response and add it to the context.
-func attachVariablesFromResponseJson(variable model.Variable, response model.Response) model.ResultVariable {
+// If the JSONPath of the variable could target many values, the first one is used.
+func attachVariablesFromResponseJson(variable model.Variable, response model.Response, ctx *context.Context) model.ResultVariable {
 
-	// Convert body to map[string]interface{}
-	body, err := util.StringToJson(response.Body)
+	values, err := util.JsonPathQuery(response.Body, variable.Property)
 	if err != nil {
 		return model.ResultVariable{Key: variable.Name, Err: err, Type: model.Created}
 	}
 
-	return attachVariablesFromResponseMap(variable, body)
+	if len(values) == 0 {
+		return model.ResultVariable{Key: variable.Name, Err: fmt.Errorf("no value found for path %q", variable.Property), Type: model.Created}
+	}
+	return attachVariableValue(variable, values[0], ctx)
 }
 
 // attachVariablesFromResponseXml extract variable from the XML response and add it to the context.
-func attachVariablesFromResponseXml(variable model.Variable, response model.Response) model.ResultVariable {
+func attachVariablesFromResponseXml(variable model.Variable, response model.Response, ctx *context.Context) model.ResultVariable {
 
 	// Convert body to map[string]interface{}
 	body, err := mxj.NewMapXml([]byte(response.Body))
@@ -196,10 +408,43 @@ func attachVariablesFromResponseXml(variable model.Variable, response model.Resp
 		return model.ResultVariable{Key: variable.Name, Err: err, Type: model.Created}
 	}
 
-	return attachVariablesFromResponseMap(variable, body)
+	return attachVariablesFromResponseMap(variable, body, ctx)
 }
 
-func attachVariablesFromResponseMap(variable model.Variable, body map[string]interface{}) model.ResultVariable {
+// attachVariablesFromXPath extract variable from the XML response with an XPath expression and add it to the context.
+// If the expression returns many nodes, the value of the first one is used.
+func attachVariablesFromXPath(variable model.Variable, response model.Response, namespaces map[string]string,
+	ctx *context.Context) model.ResultVariable {
+	value, found, err := evaluateXPath(variable.Property, response.Body, namespaces)
+	if err != nil {
+		return model.ResultVariable{Key: variable.Name, Err: err, Type: model.Created}
+	}
+	if !found {
+		return model.ResultVariable{Key: variable.Name, Err: fmt.Errorf("no node found for %q", variable.Property), Type: model.Created}
+	}
+	if values, ok := value.([]interface{}); ok {
+		value = values[0]
+	}
+	return attachVariableValue(variable, value, ctx)
+}
+
+// attachVariablesFromResponseHtml extract variable from the HTML response with a CSS selector and add it to the context.
+// If many elements match the selector, the value of the first one is used.
+func attachVariablesFromResponseHtml(variable model.Variable, response model.Response, ctx *context.Context) model.ResultVariable {
+	value, found, err := evaluateCssSelector(variable.Property, response.Body)
+	if err != nil {
+		return model.ResultVariable{Key: variable.Name, Err: err, Type: model.Created}
+	}
+	if !found {
+		return model.ResultVariable{Key: variable.Name, Err: fmt.Errorf("no element found for %q", variable.Property), Type: model.Created}
+	}
+	if values, ok := value.([]interface{}); ok {
+		value = values[0]
+	}
+	return attachVariableValue(variable, value, ctx)
+}
+
+func attachVariablesFromResponseMap(variable model.Variable, body map[string]interface{}, ctx *context.Context) model.ResultVariable {
 
 	// Convert key name
 	jqPath := util.JsonConvertKeyName(variable.Property)
@@ -208,22 +453,29 @@ func attachVariablesFromResponseMap(variable model.Variable, body map[string]int
 	if err != nil {
 		return model.ResultVariable{Key: variable.Name, Err: err, Type: model.Created}
 	}
+	return attachVariableValue(variable, extractedKey, ctx)
+}
 
+// attachVariableValue add the value extracted from the body to the context, only scalar values are allowed.
+func attachVariableValue(variable model.Variable, extractedKey interface{}, ctx *context.Context) model.ResultVariable {
 	switch value := extractedKey.(type) {
 	case string:
-		context.GetContext().Add(variable.Name, value)
+		ctx.Add(variable.Name, value)
 		return model.ResultVariable{Key: variable.Name, NewValue: value, Type: model.Created}
 
 	case bool:
 		castValue := strconv.FormatBool(value)
-		context.GetContext().Add(variable.Name, castValue)
+		ctx.Add(variable.Name, castValue)
 		return model.ResultVariable{Key: variable.Name, NewValue: castValue, Type: model.Created}
 
 	case float64:
 		castValue := fmt.Sprintf("%g", value)
-		context.GetContext().Add(variable.Name, castValue)
+		ctx.Add(variable.Name, castValue)
 		return model.ResultVariable{Key: variable.Name, NewValue: castValue, Type: model.Created}
 
+	case nil:
+		return model.ResultVariable{Key: variable.Name, Err: errors.New("null value can not be exported as a variable"), Type: model.Created}
+
 	default:
 		return model.ResultVariable{
 			Key:  variable.Name,
@@ -234,10 +486,10 @@ func attachVariablesFromResponseMap(variable model.Variable, body map[string]int
 }
 
 // convertAndPatchToHttpRequest create the HTTP request to call.
-func convertAndPatchToHttpRequest(step model.Step) (rest.Request, []model.ResultVariable, error) {
+func convertAndPatchToHttpRequest(step model.Step, ctx *context.Context) (rest.Request, []model.ResultVariable, error) {
 
 	var result []model.ResultVariable
-	urlPatched := context.GetContext().Patch(step.URL)
+	urlPatched := ctx.Patch(step.URL)
 	baseUrl, queryParams, err := extractUrl(urlPatched)
 	if err != nil {
 		return rest.Request{}, result, err
@@ -254,24 +506,148 @@ func convertAndPatchToHttpRequest(step model.Step) (rest.Request, []model.Result
 	// Add headers from command line.
 	// It can override existing headers.
 	for key, value := range viper.GetStringMapString("headers") {
-		headers[key] = context.GetContext().Patch(value)
+		headers[key] = ctx.Patch(value)
 	}
 
 	// Patches
-	bodyPatched := patchVariable(step.Body, "body", &result)
+	body := []byte(patchVariable(step.Body, "body", &result, ctx))
+	if len(step.BodyFile) > 0 {
+		content, err := ioutil.ReadFile(ctx.Patch(step.BodyFile))
+		if err != nil {
+			return rest.Request{}, result, fmt.Errorf("impossible to read the body file: %v", err)
+		}
+		// a binary file is sent untouched
+		body = content
+		if util.IsText(content) {
+			body = []byte(patchVariable(string(content), "body", &result, ctx))
+		}
+	}
 	for key, value := range headers {
-		headers[key] = patchVariable(value, "headers."+key, &result)
+		headers[key] = patchVariable(value, "headers."+key, &result, ctx)
+	}
+
+	switch {
+	case len(step.Form) > 0:
+		body = formBody(step.Form, &result, ctx)
+		if len(headerValue(headers, "Content-Type")) == 0 {
+			headers["Content-Type"] = "application/x-www-form-urlencoded"
+		}
+
+	case step.Multipart != nil:
+		var contentType string
+		body, contentType, err = multipartBody(*step.Multipart, &result, ctx)
+		if err != nil {
+			return rest.Request{}, result, err
+		}
+		// the boundary of the Content-Type should be the one used in the body
+		for key := range headers {
+			if strings.EqualFold(key, "Content-Type") {
+				delete(headers, key)
+			}
+		}
+		headers["Content-Type"] = contentType
 	}
 
 	return rest.Request{
 		Method:      rest.Method(step.Method),
 		Headers:     headers,
 		QueryParams: queryParams,
-		Body:        []byte(bodyPatched),
+		Body:        body,
 		BaseURL:     baseUrl,
 	}, result, nil
 }
 
+// headerValue returns the value of a header, the name of the header is case insensitive.
+func headerValue(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// deleteHeader removes a header, the name of the header is case insensitive.
+func deleteHeader(headers map[string]string, name string) {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			delete(headers, key)
+		}
+	}
+}
+
+// formBody encodes the fields of a form as an application/x-www-form-urlencoded body.
+func formBody(form map[string]string, variables *[]model.ResultVariable, ctx *context.Context) []byte {
+	values := url.Values{}
+	for _, key := range sortedKeys(form) {
+		values.Set(key, patchVariable(form[key], "form."+key, variables, ctx))
+	}
+	return []byte(values.Encode())
+}
+
+// quoteEscaper escapes the quotes in the parameters of the Content-Disposition header.
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// multipartBody creates a multipart/form-data body and returns it with its Content-Type.
+// The fields are patched with the variables, the files are sent untouched.
+func multipartBody(form model.Multipart, variables *[]model.ResultVariable, ctx *context.Context) ([]byte, string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	for _, key := range sortedKeys(form.Fields) {
+		value := patchVariable(form.Fields[key], "multipart.fields."+key, variables, ctx)
+		if err := writer.WriteField(key, value); err != nil {
+			return nil, "", err
+		}
+	}
+
+	for _, file := range form.Files {
+		path := ctx.Patch(file.Path)
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, "", fmt.Errorf("impossible to read the file of the field %s: %v", file.Field, err)
+		}
+
+		filename := file.Filename
+		if len(filename) == 0 {
+			filename = filepath.Base(path)
+		}
+		contentType := file.ContentType
+		if len(contentType) == 0 {
+			contentType = mime.TypeByExtension(filepath.Ext(path))
+		}
+		if len(contentType) == 0 {
+			contentType = "application/octet-stream"
+		}
+
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			quoteEscaper.Replace(file.Field), quoteEscaper.Replace(filename)))
+		header.Set("Content-Type", contentType)
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := part.Write(content); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return body.Bytes(), writer.FormDataContentType(), nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // extractUrl extract URL and params to have URL and params in 2 variables.
 func extractUrl(urlToParse string) (string, map[string]string, error) {
 	u, err := url.Parse(urlToParse)
@@ -291,24 +667,26 @@ func extractUrl(urlToParse string) (string, map[string]string, error) {
 
 // patchVariable is applying a patch with the context on the "initial" string and also
 // update the slice of 'variables"
-func patchVariable(initial string, name string, variables *[]model.ResultVariable) string {
+func patchVariable(initial string, name string, variables *[]model.ResultVariable, ctx *context.Context) string {
 	initialValue := string(initial)
-	patchedValue := context.GetContext().Patch(initial)
+	patchedValue, err := ctx.PatchWithError(initial)
 
-	if initialValue != patchedValue {
+	if initialValue != patchedValue || err != nil {
 		*variables = append(*variables, model.ResultVariable{
 			Type:     model.Used,
 			NewValue: patchedValue,
 			Key:      name,
+			Err:      err,
 		})
 	}
 
 	return patchedValue
 }
 
-// printRestRequest is logging a user friendly description of the request.
-func printRestRequest(req rest.Request, appliedVar []model.ResultVariable) {
-	logrus.Info("------------------------")
+// printRestRequest is logging a user friendly description of the request, the secrets are masked.
+func printRestRequest(req rest.Request, appliedVar []model.ResultVariable, logger *logrus.Logger) {
+	registry := secret.GetRegistry()
+	logger.Info("------------------------")
 	// Compose URL
 	params := ""
 	for key, value := range req.QueryParams {
@@ -319,23 +697,23 @@ func printRestRequest(req rest.Request, appliedVar []model.ResultVariable) {
 		}
 		params += fmt.Sprintf("%s=%s", key, value)
 	}
-	url := req.BaseURL + params
+	url := registry.Mask(req.BaseURL + params)
 
-	logrus.Infof("%s %s", req.Method, url)
+	logger.Infof("%s %s", req.Method, url)
 	if len(req.Body) > 0 {
-		logrus.Debugf("Body: %v", string(req.Body))
+		logger.Debugf("Body: %v", registry.Mask(string(req.Body)))
 	}
 	if len(req.Headers) > 0 {
-		logrus.Debug("Headers:")
+		logger.Debug("Headers:")
 		for key, value := range req.Headers {
-			logrus.Debugf("\t%s: %s", key, value)
+			logger.Debugf("\t%s: %s", key, registry.MaskHeader(key, value))
 		}
 	}
 	if len(appliedVar) > 0 {
-		logrus.Info("Variables Used:")
+		logger.Info("Variables Used:")
 		for _, currentVar := range appliedVar {
-			currentVar.Print()
+			currentVar.Print(logger)
 		}
 	}
-	logrus.Infof("---")
+	logger.Infof("---")
 }
@@ -1,14 +1,25 @@
 package controller_test
 
 import (
+	"bytes"
+	context2 "context"
+	"crypto/tls"
 	"github.com/spf13/viper"
 	"github.com/thomaspoignant/api-scenario/pkg/context"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/sendgrid/rest"
 	"github.com/thomaspoignant/api-scenario/pkg/controller"
 	"github.com/thomaspoignant/api-scenario/pkg/model"
+	"github.com/thomaspoignant/api-scenario/pkg/secret"
 	"github.com/thomaspoignant/api-scenario/test"
 )
 
@@ -21,7 +32,7 @@ func TestStepPause(t *testing.T) {
 	}
 
 	start := time.Now()
-	if _, err := sc.Run(step); err != nil {
+	if _, err := sc.Run(step, context.GetContext()); err != nil {
 		t.Fatalf("Unexpected error %v", err)
 	}
 	end := time.Now()
@@ -41,7 +52,7 @@ func TestOutputPause(t *testing.T) {
 
 	want := "------------------------\nWaiting for 1s\n"
 	got := test.CaptureOutput(func() {
-		if _, err := sc.Run(step); err != nil {
+		if _, err := sc.Run(step, context.GetContext()); err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	})
@@ -113,7 +124,7 @@ func TestRequestValidJson(t *testing.T) {
 			},
 		},
 	}
-	got, _ := sc.Run(step)
+	got, _ := sc.Run(step, context.GetContext())
 
 	test.Equals(t, "StepType should be request", model.RequestStep, got.StepType)
 	test.Assert(t, got.StepTime > 0, "StepTime should be positive")
@@ -142,6 +153,313 @@ func TestRequestValidJson(t *testing.T) {
 	test.Equals(t, "Should have create 7 variables", 7, len(got.VariablesCreated))
 }
 
+func TestRequestVariablesFromRegex(t *testing.T) {
+	test.SetupLog()
+	sc := controller.NewStepController(&test.ClientMock{}, controller.NewAssertionController())
+	ctx := context.NewContext()
+	step := model.Step{
+		URL:      "http://test.com/regex?testNumber=1",
+		StepType: model.RequestStep,
+		Variables: []model.Variable{
+			{Source: model.ResponseHeader, Property: "Content-Type", Name: "format", Regex: `application/(?P<format>\w+)`, Group: "format"},
+			{Source: model.ResponseText, Name: "param2", Regex: `"param2":(\d+)`},
+			{Source: model.ResponseText, Name: "whole", Regex: `"hello":"\w+"`},
+			{Source: model.ResponseText, Name: "not_found", Regex: `"param3":(\d+)`},
+			{Source: model.ResponseText, Name: "invalid_group", Regex: `"param2":(\d+)`, Group: "2"},
+		},
+	}
+	got, err := sc.Run(step, ctx)
+	test.Ok(t, err)
+
+	test.Equals(t, "Should have create 5 variables", 5, len(got.VariablesCreated))
+	value, _ := ctx.Get("format")
+	test.Equals(t, "Should extract the named group", "json", value)
+	value, _ = ctx.Get("param2")
+	test.Equals(t, "Should extract the first group by default", "123", value)
+	value, _ = ctx.Get("whole")
+	test.Equals(t, "Should extract the whole match without group", `"hello":"world"`, value)
+	test.Equals(t, "Should fail if the regex does not match",
+		`the regex "\"param3\":(\\d+)" did not match`, got.VariablesCreated[3].Err.Error())
+	test.Equals(t, "Should fail if the group does not exist",
+		`the regex "\"param2\":(\\d+)" has no group 2`, got.VariablesCreated[4].Err.Error())
+}
+
+func TestRequestVariablesFromJsonPath(t *testing.T) {
+	test.SetupLog()
+	sc := controller.NewStepController(&test.ClientMock{}, controller.NewAssertionController())
+	ctx := context.NewContext()
+	step := model.Step{
+		URL:      "http://test.com/jsonpath?testNumber=1",
+		StepType: model.RequestStep,
+		Variables: []model.Variable{
+			{Source: model.ResponseJson, Property: "$[?(@ == 123)]", Name: "number"},
+			{Source: model.ResponseJson, Property: "$.*", Name: "first"},
+			{Source: model.ResponseJson, Property: "$[?(@ == 'unknown')]", Name: "unknown"},
+		},
+	}
+	got, err := sc.Run(step, ctx)
+	test.Ok(t, err)
+
+	value, _ := ctx.Get("number")
+	test.Equals(t, "Should extract the value of the filter", "123", value)
+	value, _ = ctx.Get("first")
+	test.Equals(t, "Should extract the first value found", "world", value)
+	test.Equals(t, "Should fail if nothing is found", `no value found for path "$[?(@ == 'unknown')]"`, got.VariablesCreated[2].Err.Error())
+}
+
+func TestRequestVariablesFromXPath(t *testing.T) {
+	test.SetupLog()
+	sc := controller.NewStepController(&test.ClientMock{}, controller.NewAssertionController())
+	ctx := context.NewContext()
+	step := model.Step{
+		URL:      "http://test.com/xpath?testNumber=2",
+		StepType: model.RequestStep,
+		Variables: []model.Variable{
+			{Source: model.ResponseXml, Property: "/root/hello/text()", Name: "hello"},
+			{Source: model.ResponseXml, Property: "count(/root/*)", Name: "count"},
+			{Source: model.ResponseXml, Property: "/root/*", Name: "first"},
+			{Source: model.ResponseXml, Property: "/root/unknown", Name: "unknown"},
+		},
+		Assertions: []model.Assertion{
+			{Source: model.ResponseXml, Property: "/r:root/r:param2", Comparison: model.EqualNumber, Value: "123"},
+		},
+		Namespaces: map[string]string{"r": ""},
+	}
+	got, err := sc.Run(step, ctx)
+	test.Ok(t, err)
+
+	test.Equals(t, "Should use the namespaces of the step", true, got.Assertions[0].Success)
+	value, _ := ctx.Get("hello")
+	test.Equals(t, "Should extract the text", "world", value)
+	value, _ = ctx.Get("count")
+	test.Equals(t, "Should extract the result of the function", "3", value)
+	value, _ = ctx.Get("first")
+	test.Equals(t, "Should extract the first node", "world", value)
+	test.Equals(t, "Should fail if no node is found", `no node found for "/root/unknown"`, got.VariablesCreated[3].Err.Error())
+}
+
+func TestRequestVariablesFromHtml(t *testing.T) {
+	test.SetupLog()
+	sc := controller.NewStepController(&test.ClientMock{}, controller.NewAssertionController())
+	ctx := context.NewContext()
+	step := model.Step{
+		URL:      "http://test.com/login?testNumber=3",
+		StepType: model.RequestStep,
+		Variables: []model.Variable{
+			{Source: model.ResponseHtml, Property: "form input[name=csrf_token]@value", Name: "csrf"},
+			{Source: model.ResponseHtml, Property: "form@method", Name: "method"},
+			{Source: model.ResponseHtml, Property: "form@target", Name: "target"},
+		},
+	}
+	got, err := sc.Run(step, ctx)
+	test.Ok(t, err)
+
+	value, _ := ctx.Get("csrf")
+	test.Equals(t, "Should extract the attribute", "a1b2c3", value)
+	value, _ = ctx.Get("method")
+	test.Equals(t, "Should extract the attribute of the form", "post", value)
+	test.Equals(t, "Should fail if no element has the attribute", `no element found for "form@target"`, got.VariablesCreated[2].Err.Error())
+}
+
+func TestRequestCookieJar(t *testing.T) {
+	test.SetupLog()
+	sc := controller.NewStepController(&test.ClientMock{}, controller.NewAssertionController())
+	ctx := context.NewContext()
+	step := model.Step{
+		URL:      "https://test.com/login?testNumber=4",
+		StepType: model.RequestStep,
+		Variables: []model.Variable{
+			{Source: model.ResponseCookie, Property: "session", Name: "session"},
+			{Source: model.ResponseCookie, Property: "session.http_only", Name: "httpOnly"},
+		},
+	}
+
+	got, err := sc.Run(step, ctx)
+	test.Ok(t, err)
+	test.Equals(t, "Should not send cookie on the first request", "", got.Response.Body)
+	value, _ := ctx.Get("session")
+	test.Equals(t, "Should extract the value of the cookie", "abc", value)
+	value, _ = ctx.Get("httpOnly")
+	test.Equals(t, "Should extract the flag of the cookie", "true", value)
+
+	got, err = sc.Run(step, ctx)
+	test.Ok(t, err)
+	test.Equals(t, "Should send the cookie received", "session=abc", got.Response.Body)
+
+	step.Cookies = model.CookiesDisabled
+	got, err = sc.Run(step, ctx)
+	test.Ok(t, err)
+	test.Equals(t, "Should not send cookie when the cookies are disabled", "", got.Response.Body)
+
+	step.Cookies = model.CookiesClear
+	got, err = sc.Run(step, ctx)
+	test.Ok(t, err)
+	test.Equals(t, "Should not send cookie after clearing the jar", "", got.Response.Body)
+}
+
+func TestRequestCookieJarByScenario(t *testing.T) {
+	test.SetupLog()
+	sc := controller.NewStepController(&test.ClientMock{}, controller.NewAssertionController())
+	ctx := context.NewContext()
+	step := model.Step{URL: "https://test.com/login?testNumber=4", StepType: model.RequestStep}
+
+	_, err := sc.Run(step, ctx)
+	test.Ok(t, err)
+	got, err := sc.Run(step, ctx.Clone())
+	test.Ok(t, err)
+	test.Equals(t, "Should not share the cookies with another scenario", "", got.Response.Body)
+}
+
+func TestRequestFormBody(t *testing.T) {
+	test.SetupLog()
+	sc := controller.NewStepController(&test.ClientMock{}, controller.NewAssertionController())
+	ctx := context.NewContext()
+	ctx.Add("user", "john doe")
+	step := model.Step{
+		URL:      "http://test.com/login",
+		Method:   "POST",
+		StepType: model.RequestStep,
+		Form:     map[string]string{"username": "{{user}}", "remember": "true"},
+	}
+	got, err := sc.Run(step, ctx)
+	test.Ok(t, err)
+	test.Equals(t, "Should encode the form", "remember=true&username=john+doe", string(got.Request.Body))
+	test.Equals(t, "Should set the content type", "application/x-www-form-urlencoded", got.Request.Headers["Content-Type"])
+	test.Equals(t, "Should display the patched fields", "form.username", got.VariablesApplied[0].Key)
+}
+
+func TestRequestMultipartBody(t *testing.T) {
+	test.SetupLog()
+	sc := controller.NewStepController(&test.ClientMock{}, controller.NewAssertionController())
+	ctx := context.NewContext()
+	ctx.Add("user_id", "42")
+	step := model.Step{
+		URL:      "http://test.com/avatar",
+		Method:   "POST",
+		StepType: model.RequestStep,
+		Headers:  map[string][]string{"content-type": {"text/plain"}},
+		Multipart: &model.Multipart{
+			Fields: map[string]string{"description": "avatar of {{user_id}}"},
+			Files: []model.MultipartFile{
+				{Field: "avatar", Path: "../../testdata/upload/avatar.png"},
+				{Field: "notes", Path: "../../testdata/directory/readme.txt", Filename: "notes.txt", ContentType: "text/markdown"},
+			},
+		},
+	}
+	got, err := sc.Run(step, ctx)
+	test.Ok(t, err)
+
+	contentType := got.Request.Headers["Content-Type"]
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	test.Ok(t, err)
+	test.Equals(t, "Should replace the content type", "multipart/form-data", mediaType)
+	_, ok := got.Request.Headers["content-type"]
+	test.Equals(t, "Should have only one content type", false, ok)
+
+	avatar, _ := ioutil.ReadFile("../../testdata/upload/avatar.png")
+	reader := multipart.NewReader(bytes.NewReader(got.Request.Body), params["boundary"])
+	form, err := reader.ReadForm(1 << 20)
+	test.Ok(t, err)
+	test.Equals(t, "Should patch the fields", []string{"avatar of 42"}, form.Value["description"])
+	test.Equals(t, "Should use the name of the file", "avatar.png", form.File["avatar"][0].Filename)
+	test.Equals(t, "Should guess the content type", "image/png", form.File["avatar"][0].Header.Get("Content-Type"))
+	file, _ := form.File["avatar"][0].Open()
+	content, _ := ioutil.ReadAll(file)
+	test.Equals(t, "Should send the file untouched", avatar, content)
+	test.Equals(t, "Should use the filename", "notes.txt", form.File["notes"][0].Filename)
+	test.Equals(t, "Should use the content type", "text/markdown", form.File["notes"][0].Header.Get("Content-Type"))
+}
+
+func TestRequestMultipartMissingFile(t *testing.T) {
+	test.SetupLog()
+	sc := controller.NewStepController(&test.ClientMock{}, controller.NewAssertionController())
+	step := model.Step{
+		URL:       "http://test.com/avatar",
+		Method:    "POST",
+		StepType:  model.RequestStep,
+		Multipart: &model.Multipart{Files: []model.MultipartFile{{Field: "avatar", Path: "unknown.png"}}},
+	}
+	_, err := sc.Run(step, context.NewContext())
+	test.Ko(t, err)
+	test.Equals(t, "Should fail if the file does not exist",
+		"impossible to convert the request [impossible to read the file of the field avatar: open unknown.png: no such file or directory]", err.Error())
+}
+
+func TestRequestBodyFile(t *testing.T) {
+	test.SetupLog()
+	sc := controller.NewStepController(&test.ClientMock{}, controller.NewAssertionController())
+	ctx := context.NewContext()
+	ctx.Add("name", "john")
+	step := model.Step{
+		URL:      "http://test.com/users",
+		Method:   "POST",
+		StepType: model.RequestStep,
+		BodyFile: "../../testdata/upload/user.json",
+	}
+	got, err := sc.Run(step, ctx)
+	test.Ok(t, err)
+	test.Equals(t, "Should patch the body file", "{\n  \"name\": \"john\",\n  \"job\": \"developer\"\n}\n", string(got.Request.Body))
+
+	step.BodyFile = "../../testdata/upload/avatar.png"
+	got, err = sc.Run(step, ctx)
+	test.Ok(t, err)
+	avatar, _ := ioutil.ReadFile("../../testdata/upload/avatar.png")
+	test.Equals(t, "Should send a binary file untouched", avatar, got.Request.Body)
+
+	step.BodyFile = "../../testdata/upload/unknown.json"
+	_, err = sc.Run(step, ctx)
+	test.Ko(t, err)
+}
+
+func TestRequestMissingEnvVariable(t *testing.T) {
+	test.SetupLog()
+	sc := controller.NewStepController(&test.ClientMock{}, controller.NewAssertionController())
+	step := model.Step{
+		URL:      "http://test.com/users",
+		Method:   "GET",
+		StepType: model.RequestStep,
+		Headers: map[string][]string{
+			"Authorization": {"Bearer {{env(API_SCENARIO_TEST_UNKNOWN)}}"},
+		},
+	}
+	got, err := sc.Run(step, context.NewContext())
+
+	test.Ok(t, err)
+	test.Equals(t, "Should record the header", 1, len(got.VariablesApplied))
+	test.Equals(t, "Should record the header name", "headers.Authorization", got.VariablesApplied[0].Key)
+	test.Equals(t, "Should record the error", "the environment variable API_SCENARIO_TEST_UNKNOWN is not set", got.VariablesApplied[0].Err.Error())
+	test.Equals(t, "Step should be failed", false, got.IsSuccess())
+}
+
+func TestRequestSecretsMasked(t *testing.T) {
+	test.SetupLog()
+	defer secret.GetRegistry().Reset()
+	sc := controller.NewStepController(&test.ClientMock{}, controller.NewAssertionController())
+	ctx := context.NewContext()
+	ctx.Add("password", "p4ssw0rd")
+	step := model.Step{
+		URL:      "http://test.com/login?testNumber=1",
+		Method:   "POST",
+		StepType: model.RequestStep,
+		Body:     `{"password":"{{password}}"}`,
+		Headers: map[string][]string{
+			"Authorization": {"Bearer Token123"},
+		},
+	}
+
+	var got model.ResultStep
+	output := test.CaptureOutput(func() {
+		var err error
+		got, err = sc.Run(step, ctx)
+		test.Ok(t, err)
+	})
+	test.Assert(t, strings.Contains(output, "Authorization: ****"), "the header should be masked: %s", output)
+	test.Assert(t, strings.Contains(output, `Body: {"password":"****"}`), "the body should be masked: %s", output)
+	test.Assert(t, !strings.Contains(output, "p4ssw0rd") && !strings.Contains(output, "Token123"), "the secrets should not be displayed: %s", output)
+	test.Equals(t, "Should send the secrets on the wire", "Bearer Token123", got.Request.Headers["Authorization"])
+	test.Equals(t, "Should send the secrets on the wire", `{"password":"p4ssw0rd"}`, string(got.Request.Body))
+}
+
 func TestRequestInvalidUrl(t *testing.T) {
 	test.SetupLog()
 	testNumber := "1"
@@ -155,7 +473,7 @@ func TestRequestInvalidUrl(t *testing.T) {
 			"Content-Type": {"other_test_{{random_int(1,1)}}"},
 		},
 	}
-	_, err := sc.Run(step)
+	_, err := sc.Run(step, context.GetContext())
 
 	test.Assert(t, err != nil, "Should have an error")
 	want := "impossible to convert the request [parse \"http://{elfdj/1/1?param1=param1_1&testNumber=1\": invalid character \"{\" in host name]"
@@ -190,7 +508,7 @@ func TestRequestValidXml(t *testing.T) {
 			},
 		},
 	}
-	got, _ := sc.Run(step)
+	got, _ := sc.Run(step, context.GetContext())
 
 	test.Equals(t, "StepType should be request", model.RequestStep, got.StepType)
 	test.Assert(t, got.StepTime > 0, "StepTime should be positive")
@@ -202,3 +520,173 @@ func TestRequestValidXml(t *testing.T) {
 	test.Equals(t, "Should have valid assertion", true, got.Assertions[0].Success)
 	test.Equals(t, "Should have create 1 variables", 1, len(got.VariablesCreated))
 }
+
+type timeoutClientMock struct {
+	timeout time.Duration
+}
+
+func (c *timeoutClientMock) Send(request rest.Request, timeout time.Duration, jar http.CookieJar,
+	tlsConfig *model.TLSConfig) (*rest.Response, *tls.ConnectionState, error) {
+	c.timeout = timeout
+	return nil, nil, &url.Error{Op: "Get", URL: request.BaseURL, Err: context2.DeadlineExceeded}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	test.SetupLog()
+	client := &timeoutClientMock{}
+	sc := controller.NewStepController(client, controller.NewAssertionController())
+
+	viper.Set("timeout", 30)
+	defer viper.Set("timeout", 0)
+
+	step := model.Step{
+		StepType: model.RequestStep,
+		URL:      "http://test.com/timeout",
+		Method:   "GET",
+		Timeout:  2,
+	}
+	got, err := sc.Run(step, context.GetContext())
+
+	test.Ok(t, err)
+	test.Equals(t, "Should use the timeout of the step", 2*time.Second, client.timeout)
+	test.Equals(t, "Should have a timeout error", "the request timed out after 2s", got.Err.Error())
+	test.Equals(t, "Step should be failed", false, got.IsSuccess())
+
+	step.Timeout = 0
+	_, err = sc.Run(step, context.GetContext())
+	test.Ok(t, err)
+	test.Equals(t, "Should use the default timeout", 30*time.Second, client.timeout)
+}
+
+// retryClientMock returns a 503 status until the call number successAt.
+type retryClientMock struct {
+	calls     int
+	successAt int
+}
+
+func (c *retryClientMock) Send(request rest.Request, timeout time.Duration, jar http.CookieJar,
+	tlsConfig *model.TLSConfig) (*rest.Response, *tls.ConnectionState, error) {
+	c.calls++
+	if c.calls < c.successAt {
+		return &rest.Response{StatusCode: 503, Body: `{"status":"pending"}`}, nil, nil
+	}
+	return &rest.Response{StatusCode: 200, Body: `{"status":"done","id":"42"}`}, nil, nil
+}
+
+func TestRequestRetryUntilAssertionsPass(t *testing.T) {
+	test.SetupLog()
+	client := &retryClientMock{successAt: 3}
+	sc := controller.NewStepController(client, controller.NewAssertionController())
+
+	step := model.Step{
+		StepType: model.RequestStep,
+		URL:      "http://test.com/job",
+		Method:   "GET",
+		Retry:    &model.Retry{MaxAttempts: 5},
+		Assertions: []model.Assertion{
+			{Source: model.ResponseStatus, Comparison: model.EqualNumber, Value: "200"},
+		},
+		Variables: []model.Variable{
+			{Source: model.ResponseJson, Property: "id", Name: "job_id"},
+		},
+	}
+	got, err := sc.Run(step, context.GetContext())
+
+	test.Ok(t, err)
+	test.Equals(t, "Should stop calling when the assertions pass", 3, client.calls)
+	test.Equals(t, "Should record every attempt", 3, len(got.Attempts))
+	test.Equals(t, "First attempt should be failed", false, got.Attempts[0].IsSuccess())
+	test.Equals(t, "Step should be a success", true, got.IsSuccess())
+	test.Equals(t, "Variables should be created from the last attempt", "42", got.VariablesCreated[0].NewValue)
+}
+
+func TestRequestRetryUntil(t *testing.T) {
+	test.SetupLog()
+	client := &retryClientMock{successAt: 2}
+	sc := controller.NewStepController(client, controller.NewAssertionController())
+
+	step := model.Step{
+		StepType: model.RequestStep,
+		URL:      "http://test.com/job",
+		Method:   "GET",
+		Retry: &model.Retry{
+			MaxAttempts: 5,
+			Until: []model.Assertion{
+				{Source: model.ResponseJson, Property: "status", Comparison: model.Equal, Value: "done"},
+			},
+		},
+		Assertions: []model.Assertion{
+			{Source: model.ResponseJson, Property: "id", Comparison: model.Equal, Value: "43"},
+		},
+	}
+	got, err := sc.Run(step, context.GetContext())
+
+	test.Ok(t, err)
+	test.Equals(t, "Should stop calling when the until assertions pass", 2, client.calls)
+	test.Equals(t, "Should have until and step assertions", 2, len(got.Assertions))
+	test.Equals(t, "Step should be failed because of the step assertion", false, got.IsSuccess())
+}
+
+func TestRequestRetryNoAttemptLeft(t *testing.T) {
+	test.SetupLog()
+	client := &retryClientMock{successAt: 10}
+	sc := controller.NewStepController(client, controller.NewAssertionController())
+
+	step := model.Step{
+		StepType: model.RequestStep,
+		URL:      "http://test.com/job",
+		Method:   "GET",
+		Retry:    &model.Retry{MaxAttempts: 3},
+		Assertions: []model.Assertion{
+			{Source: model.ResponseStatus, Comparison: model.EqualNumber, Value: "200"},
+		},
+	}
+	got, err := sc.Run(step, context.GetContext())
+
+	test.Ok(t, err)
+	test.Equals(t, "Should call max_attempts times", 3, client.calls)
+	test.Equals(t, "Should record every attempt", 3, len(got.Attempts))
+	test.Equals(t, "Step should be failed", false, got.IsSuccess())
+}
+
+// injectionClientMock returns a response with builtin functions in its values and records the requests.
+type injectionClientMock struct {
+	requests []rest.Request
+}
+
+func (c *injectionClientMock) Send(request rest.Request, timeout time.Duration, jar http.CookieJar,
+	tlsConfig *model.TLSConfig) (*rest.Response, *tls.ConnectionState, error) {
+	c.requests = append(c.requests, request)
+	body := `{"next":"{{env(API_SCENARIO_TEST_SECRET)}}","file":"{{file(../../testdata/env/token.txt)}}"}`
+	return &rest.Response{StatusCode: 200, Body: body}, nil, nil
+}
+
+func TestRequestVariablesFromResponseNotExpanded(t *testing.T) {
+	test.SetupLog()
+	os.Setenv("API_SCENARIO_TEST_SECRET", "s3cr3t")
+	defer os.Unsetenv("API_SCENARIO_TEST_SECRET")
+	client := &injectionClientMock{}
+	sc := controller.NewStepController(client, controller.NewAssertionController())
+	ctx := context.NewContext()
+
+	_, err := sc.Run(model.Step{
+		StepType: model.RequestStep,
+		URL:      "http://test.com/start",
+		Method:   "GET",
+		Variables: []model.Variable{
+			{Source: model.ResponseJson, Property: "next", Name: "next"},
+			{Source: model.ResponseJson, Property: "file", Name: "file"},
+		},
+	}, ctx)
+	test.Ok(t, err)
+	_, err = sc.Run(model.Step{
+		StepType: model.RequestStep,
+		URL:      "http://test.com/next?q={{next}}",
+		Method:   "POST",
+		Body:     `{"file":"{{file}}"}`,
+	}, ctx)
+	test.Ok(t, err)
+
+	test.Equals(t, "Should not read the environment variable", "{{env(API_SCENARIO_TEST_SECRET)}}", client.requests[1].QueryParams["q"])
+	test.Equals(t, "Should not read the file", `{"file":"{{file(../../testdata/env/token.txt)}}"}`, string(client.requests[1].Body))
+}
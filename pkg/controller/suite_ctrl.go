@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/thomaspoignant/api-scenario/pkg/context"
+	"github.com/thomaspoignant/api-scenario/pkg/model"
+)
+
+type SuiteController interface {
+	Run(scenarios []model.Scenario, ctx *context.Context, parallel int) model.SuiteResult
+}
+
+func NewSuiteController(scenarioCtrl ScenarioController) SuiteController {
+	return &suiteControllerImpl{
+		scenarioController: scenarioCtrl,
+	}
+}
+
+type suiteControllerImpl struct {
+	scenarioController ScenarioController
+	outputMutex        sync.Mutex
+}
+
+// Run is executing all the scenarios, each of them with its own copy of the context.
+// If parallel is greater than 1, the scenarios are executed concurrently by parallel workers
+// and the output of every scenario is displayed when the scenario is over.
+func (s *suiteControllerImpl) Run(scenarios []model.Scenario, ctx *context.Context, parallel int) model.SuiteResult {
+	results := make([]model.ScenarioResult, len(scenarios))
+
+	if parallel <= 1 {
+		for index, scenario := range scenarios {
+			results[index] = s.scenarioController.Run(scenario, ctx.Clone())
+		}
+		return model.SuiteResult{ScenarioResults: results}
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < parallel; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				results[index] = s.runBuffered(scenarios[index], ctx)
+			}
+		}()
+	}
+
+	for index := range scenarios {
+		jobs <- index
+	}
+	close(jobs)
+	wg.Wait()
+
+	return model.SuiteResult{ScenarioResults: results}
+}
+
+// runBuffered is running a scenario with a logger writing in a buffer, the buffer is flushed
+// to the output of the context logger at the end of the scenario to avoid interleaving logs.
+func (s *suiteControllerImpl) runBuffered(scenario model.Scenario, ctx *context.Context) model.ScenarioResult {
+	var buf bytes.Buffer
+	parentLogger := ctx.Logger()
+	logger := &logrus.Logger{
+		Out:       &buf,
+		Formatter: parentLogger.Formatter,
+		Hooks:     make(logrus.LevelHooks),
+		Level:     parentLogger.GetLevel(),
+		ExitFunc:  parentLogger.ExitFunc,
+	}
+
+	scenarioCtx := ctx.Clone()
+	scenarioCtx.SetLogger(logger)
+	result := s.scenarioController.Run(scenario, scenarioCtx)
+
+	s.outputMutex.Lock()
+	defer s.outputMutex.Unlock()
+	_, _ = buf.WriteTo(parentLogger.Out)
+	return result
+}
@@ -0,0 +1,79 @@
+package controller_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/thomaspoignant/api-scenario/pkg/context"
+	"github.com/thomaspoignant/api-scenario/pkg/controller"
+	"github.com/thomaspoignant/api-scenario/pkg/model"
+	"github.com/thomaspoignant/api-scenario/test"
+)
+
+// MockVariableStepController creates a variable named "step" and logs the steps of the scenario.
+type MockVariableStepController struct{}
+
+func (m MockVariableStepController) Run(step model.Step, ctx *context.Context) (model.ResultStep, error) {
+	previous := ctx.Patch("{{step}}")
+	ctx.Add("step", step.URL)
+	ctx.Logger().Infof("%s after %s", step.URL, previous)
+	return model.ResultStep{StepType: model.RequestStep}, nil
+}
+
+func newSuiteScenarios(number int) []model.Scenario {
+	var scenarios []model.Scenario
+	for i := 0; i < number; i++ {
+		name := string(rune('A' + i))
+		scenarios = append(scenarios, model.Scenario{
+			Name: name,
+			Steps: []model.Step{
+				{StepType: model.RequestStep, URL: name + "1"},
+				{StepType: model.RequestStep, URL: name + "2"},
+			},
+		})
+	}
+	return scenarios
+}
+
+func TestSuiteSequential(t *testing.T) {
+	test.SetupLog()
+	ctx := context.NewContext()
+	ctx.Add("step", "init")
+	ctrl := controller.NewSuiteController(controller.NewScenarioController(MockVariableStepController{}))
+
+	var got model.SuiteResult
+	output := test.CaptureOutput(func() {
+		got = ctrl.Run(newSuiteScenarios(2), ctx, 1)
+	})
+
+	test.Equals(t, "Should have a result per scenario", 2, len(got.ScenarioResults))
+	test.Equals(t, "Results should be in the same order", "A", got.ScenarioResults[0].Name)
+	test.Equals(t, "Results should be in the same order", "B", got.ScenarioResults[1].Name)
+	test.Assert(t, strings.Contains(output, "B1 after init\n"), "Each scenario should start from the initial context: %s", output)
+	test.Equals(t, "Initial context should not be modified", "init", ctx.Patch("{{step}}"))
+}
+
+func TestSuiteParallel(t *testing.T) {
+	test.SetupLog()
+	ctx := context.NewContext()
+	ctx.Add("step", "init")
+	ctrl := controller.NewSuiteController(controller.NewScenarioController(MockVariableStepController{}))
+
+	scenarios := newSuiteScenarios(10)
+	var got model.SuiteResult
+	output := test.CaptureOutput(func() {
+		got = ctrl.Run(scenarios, ctx, 4)
+	})
+
+	test.Equals(t, "Should have a result per scenario", len(scenarios), len(got.ScenarioResults))
+	for index, scenario := range scenarios {
+		test.Equals(t, "Results should be in the same order", scenario.Name, got.ScenarioResults[index].Name)
+
+		// The output of a scenario should not be interleaved with another one.
+		wantOutput := "Running api-scenario: " + scenario.Name + " ()\n\n\n" +
+			scenario.Name + "1 after init\n" +
+			scenario.Name + "2 after " + scenario.Name + "1\n"
+		test.Assert(t, strings.Contains(output, wantOutput), "Output should contain %q, got %s", wantOutput, output)
+	}
+	test.Equals(t, "Initial context should not be modified", "init", ctx.Patch("{{step}}"))
+}
@@ -0,0 +1,462 @@
+package controller
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/thomaspoignant/api-scenario/pkg/context"
+	"github.com/thomaspoignant/api-scenario/pkg/model"
+	"github.com/thomaspoignant/api-scenario/pkg/util"
+)
+
+// ValidationError describes a problem found in a scenario.
+type ValidationError struct {
+	File      string
+	StepIndex int
+	Message   string
+}
+
+func (e ValidationError) Error() string {
+	if e.StepIndex < 0 {
+		return fmt.Sprintf("%s: %s", e.File, e.Message)
+	}
+	return fmt.Sprintf("%s: step #%d: %s", e.File, e.StepIndex+1, e.Message)
+}
+
+// variableRegex matches the variables used in a scenario, builtin functions with parameters are ignored.
+var variableRegex = regexp.MustCompile(`{{([^{}()]+)}}`)
+
+var httpMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace,
+}
+
+// ValidateScenario checks statically a scenario without calling any API.
+// knownVariables are the variables available before the first step (e.g. from the command line).
+func ValidateScenario(file string, scenario model.Scenario, knownVariables []string) []ValidationError {
+	var errs []ValidationError
+	addError := func(stepIndex int, format string, v ...interface{}) {
+		errs = append(errs, ValidationError{File: file, StepIndex: stepIndex, Message: fmt.Sprintf(format, v...)})
+	}
+
+	defined := map[string]bool{}
+	for _, variable := range knownVariables {
+		defined[variable] = true
+	}
+	for variable := range scenario.Variables {
+		defined[variable] = true
+	}
+	// the run is stopped before the first step if a required variable is missing
+	for _, variable := range scenario.RequiredVariables {
+		defined[variable] = true
+	}
+
+	if len(scenario.Steps) == 0 {
+		addError(-1, "the scenario has no step")
+	}
+	for _, message := range validateAuth(scenario.Auth) {
+		addError(-1, "auth: %s", message)
+	}
+	// the auth of the scenario is used by the first request, it can only use the variables defined before the steps
+	for _, name := range fieldVariables(authFields(scenario.Auth)) {
+		if !defined[name] {
+			addError(-1, "auth: the variable {{%s}} is used but never defined before", name)
+		}
+	}
+	for _, message := range validateTLS(scenario.TLS) {
+		addError(-1, "tls: %s", message)
+	}
+
+	for index, step := range scenario.Steps {
+		if !step.StepType.IsAStepType() {
+			addError(index, "%d is an invalid step_type", step.StepType)
+			continue
+		}
+
+		if step.StepType == model.Pause {
+			if step.Duration <= 0 {
+				addError(index, "a pause step should have a duration greater than 0")
+			}
+			continue
+		}
+
+		// request step
+		if len(strings.TrimSpace(step.URL)) == 0 {
+			addError(index, "a request step should have an url")
+		}
+		for _, message := range validateAuth(step.Auth) {
+			addError(index, "auth: %s", message)
+		}
+		if !isValidHttpMethod(step.Method) {
+			addError(index, "%q is not a valid HTTP method", step.Method)
+		}
+
+		bodies := 0
+		for _, isSet := range []bool{len(step.Body) > 0, len(step.BodyFile) > 0, len(step.Form) > 0, step.Multipart != nil} {
+			if isSet {
+				bodies++
+			}
+		}
+		if bodies > 1 {
+			addError(index, "only one of body, body_file, form and multipart can be used")
+		}
+		if !isFileFound(step.BodyFile) {
+			addError(index, "the body file %q does not exist", step.BodyFile)
+		}
+		if step.Multipart != nil {
+			for _, file := range step.Multipart.Files {
+				if len(file.Field) == 0 {
+					addError(index, "multipart: a file should have a field")
+				}
+				if !isFileFound(file.Path) {
+					addError(index, "multipart: the file %q does not exist", file.Path)
+				}
+			}
+		}
+
+		for _, message := range validateAssertions("assertion", step.Assertions, step.Namespaces) {
+			addError(index, "%s", message)
+		}
+
+		if step.Retry != nil {
+			if step.Retry.MaxAttempts < 1 {
+				addError(index, "retry: max_attempts should be greater than 0")
+			}
+			if step.Retry.Interval < 0 || step.Retry.Backoff < 0 {
+				addError(index, "retry: interval and backoff should be positive")
+			}
+			for _, message := range validateAssertions("retry until", step.Retry.Until, step.Namespaces) {
+				addError(index, "%s", message)
+			}
+		}
+
+		for _, variable := range step.Variables {
+			if !variable.Source.IsASource() {
+				addError(index, "variable %q: %d is an invalid source", variable.Name, variable.Source)
+			}
+			if len(variable.Name) == 0 {
+				addError(index, "a variable should have a name")
+			}
+			if variable.Source == model.ResponseJson {
+				if _, err := util.ParseJsonPath(variable.Property); err != nil {
+					addError(index, "variable %q: %v", variable.Name, err)
+				}
+			}
+			if variable.Source == model.ResponseXml && isXPath(variable.Property) {
+				if _, err := compileXPath(variable.Property, step.Namespaces); err != nil {
+					addError(index, "variable %q: %v", variable.Name, err)
+				}
+			}
+			if variable.Source == model.ResponseHtml {
+				if _, _, err := compileCssProperty(variable.Property); err != nil {
+					addError(index, "variable %q: %v", variable.Name, err)
+				}
+			}
+			if variable.Source == model.ResponseTLS {
+				if err := checkTLSProperty(variable.Property); err != nil {
+					addError(index, "variable %q: %v", variable.Name, err)
+				}
+			}
+			if len(variable.Regex) > 0 {
+				if variable.Source != model.ResponseText && variable.Source != model.ResponseHeader {
+					addError(index, "variable %q: a regex can only be used with response_text and response_header", variable.Name)
+				}
+				if regex, err := regexp.Compile(variable.Regex); err != nil {
+					addError(index, "variable %q: invalid regex %q", variable.Name, variable.Regex)
+				} else if _, err := regexGroupIndex(regex, variable.Group); err != nil {
+					addError(index, "variable %q: %v", variable.Name, err)
+				}
+			}
+		}
+
+		// Check that every variable used by the step is already defined.
+		for _, name := range usedVariables(step) {
+			if !defined[name] {
+				addError(index, "the variable {{%s}} is used but never defined before", name)
+			}
+		}
+
+		for _, variable := range step.Variables {
+			defined[variable.Name] = true
+		}
+	}
+	return errs
+}
+
+// validateAssertions checks the source and the comparison of a list of assertions.
+func validateAssertions(name string, assertions []model.Assertion, namespaces map[string]string) []string {
+	var messages []string
+	for index, assertion := range assertions {
+		if !assertion.Source.IsASource() {
+			messages = append(messages, fmt.Sprintf("%s #%d: %d is an invalid source", name, index+1, assertion.Source))
+			continue
+		}
+		if !assertion.Comparison.IsAComparison() {
+			messages = append(messages, fmt.Sprintf("%s #%d: %d is an invalid comparison", name, index+1, assertion.Comparison))
+			continue
+		}
+		if !IsComparisonSupported(assertion.Source, assertion.Comparison) {
+			messages = append(messages, fmt.Sprintf("%s #%d: the comparison %s is not supported for the source %s",
+				name, index+1, assertion.Comparison, assertion.Source))
+		}
+		if len(assertion.Value) > 0 && len(assertion.ValueFile) > 0 {
+			messages = append(messages, fmt.Sprintf("%s #%d: only one of value and value_file can be used", name, index+1))
+		}
+		if !isFileFound(assertion.ValueFile) {
+			messages = append(messages, fmt.Sprintf("%s #%d: the value file %q does not exist", name, index+1, assertion.ValueFile))
+		}
+		if needsProperty(assertion.Source) && len(assertion.Property) == 0 {
+			messages = append(messages, fmt.Sprintf("%s #%d: a property is needed for the source %s", name, index+1, assertion.Source))
+		}
+		if assertion.Source == model.ResponseJson {
+			if _, err := util.ParseJsonPath(assertion.Property); err != nil {
+				messages = append(messages, fmt.Sprintf("%s #%d: %v", name, index+1, err))
+			}
+		}
+		if assertion.Source == model.ResponseXml && isXPath(assertion.Property) {
+			if _, err := compileXPath(assertion.Property, namespaces); err != nil {
+				messages = append(messages, fmt.Sprintf("%s #%d: %v", name, index+1, err))
+			}
+		}
+		if assertion.Source == model.ResponseHtml && len(assertion.Property) > 0 {
+			if _, _, err := compileCssProperty(assertion.Property); err != nil {
+				messages = append(messages, fmt.Sprintf("%s #%d: %v", name, index+1, err))
+			}
+		}
+		if assertion.Source == model.ResponseTLS && len(assertion.Property) > 0 {
+			if err := checkTLSProperty(assertion.Property); err != nil {
+				messages = append(messages, fmt.Sprintf("%s #%d: %v", name, index+1, err))
+			}
+		}
+		if isRegexComparison(assertion.Comparison) && !strings.Contains(assertion.Value, "{{") {
+			if _, err := regexp.Compile(assertion.Value); err != nil {
+				messages = append(messages, fmt.Sprintf("%s #%d: %q is not a valid regex", name, index+1, assertion.Value))
+			}
+		}
+		if assertion.Comparison == model.MatchesSchema && isInlineSchemaInvalid(assertion.Value) {
+			messages = append(messages, fmt.Sprintf("%s #%d: the value of %s should be a JSON schema or a schema file",
+				name, index+1, assertion.Comparison))
+		}
+	}
+	return messages
+}
+
+// validateAuth checks that the authentication has the settings needed by its type.
+func validateAuth(auth *model.Auth) []string {
+	if auth == nil {
+		return nil
+	}
+	if !auth.Type.IsAAuthType() {
+		return []string{fmt.Sprintf("%d is an invalid type", auth.Type)}
+	}
+
+	var messages []string
+	switch auth.Type {
+	case model.AuthBasic, model.AuthDigest:
+		credentials := auth.Basic
+		if auth.Type == model.AuthDigest {
+			credentials = auth.Digest
+		}
+		if credentials == nil {
+			return []string{fmt.Sprintf("the %s settings are missing", auth.Type)}
+		}
+		if len(credentials.Username) == 0 {
+			messages = append(messages, fmt.Sprintf("%s: a username is needed", auth.Type))
+		}
+
+	case model.AuthAPIKey:
+		if auth.APIKey == nil {
+			return []string{"the api_key settings are missing"}
+		}
+		if !auth.APIKey.In.IsAAPIKeyLocation() {
+			messages = append(messages, fmt.Sprintf("api_key: %d is an invalid location", auth.APIKey.In))
+		}
+		if len(auth.APIKey.Name) == 0 || len(auth.APIKey.Value) == 0 {
+			messages = append(messages, "api_key: a name and a value are needed")
+		}
+
+	case model.AuthAWSSigV4:
+		if auth.AWS == nil {
+			return []string{"the aws_sigv4 settings are missing"}
+		}
+		if len(auth.AWS.Service) == 0 {
+			messages = append(messages, "aws_sigv4: a service is needed")
+		}
+
+	case model.AuthOAuth2:
+		if auth.OAuth2 == nil {
+			return []string{"the oauth2 settings are missing"}
+		}
+		if !auth.OAuth2.GrantType.IsAOAuth2GrantType() {
+			messages = append(messages, fmt.Sprintf("oauth2: %d is an invalid grant_type", auth.OAuth2.GrantType))
+		}
+		if len(auth.OAuth2.TokenURL) == 0 {
+			messages = append(messages, "oauth2: a token_url is needed")
+		}
+		if len(auth.OAuth2.ClientID) == 0 {
+			messages = append(messages, "oauth2: a client_id is needed")
+		}
+		if auth.OAuth2.GrantType == model.PasswordGrant &&
+			(len(auth.OAuth2.Username) == 0 || len(auth.OAuth2.Password) == 0) {
+			messages = append(messages, "oauth2: a username and a password are needed for the password grant")
+		}
+	}
+	return messages
+}
+
+// validateTLS checks that the certificate files exist and that the client certificate has a key.
+func validateTLS(config *model.TLSConfig) []string {
+	if config == nil {
+		return nil
+	}
+	var messages []string
+	if (len(config.ClientCert) == 0) != (len(config.ClientKey) == 0) {
+		messages = append(messages, "the client_cert and the client_key should be set together")
+	}
+	for _, file := range []struct{ name, path string }{
+		{"ca_cert", config.CACert}, {"client_cert", config.ClientCert}, {"client_key", config.ClientKey},
+	} {
+		if !isFileFound(file.path) {
+			messages = append(messages, fmt.Sprintf("the %s file %q does not exist", file.name, file.path))
+		}
+	}
+	return messages
+}
+
+// isFileFound checks if a file used by a step exists, the empty paths and the paths with a variable are not checked.
+func isFileFound(path string) bool {
+	if len(path) == 0 || strings.Contains(path, "{{") {
+		return true
+	}
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// readTextFile returns the content of a text file used by a step, or an empty string if the file can not be read.
+func readTextFile(path string) string {
+	if len(path) == 0 || strings.Contains(path, "{{") {
+		return ""
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil || !util.IsText(content) {
+		return ""
+	}
+	return string(content)
+}
+
+// needsProperty checks if the assertions of a source should have a property.
+func needsProperty(source model.Source) bool {
+	return source == model.ResponseHeader || source == model.ResponseHtml || source == model.ResponseCookie ||
+		source == model.ResponseTLS
+}
+
+// isRegexComparison checks if the value of the comparison is a regex.
+func isRegexComparison(comparison model.Comparison) bool {
+	return comparison == model.MatchesRegex || comparison == model.DoesNotMatchRegex
+}
+
+// isInlineSchemaInvalid checks that the value of a matches_schema assertion is not empty
+// and if the schema is inline that it is a valid JSON.
+func isInlineSchemaInvalid(value string) bool {
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return true
+	}
+	return strings.HasPrefix(value, "{") && !util.IsJson(value)
+}
+
+// isValidHttpMethod checks if method is an HTTP method, an empty method is a GET.
+func isValidHttpMethod(method string) bool {
+	if len(method) == 0 {
+		return true
+	}
+	for _, httpMethod := range httpMethods {
+		if strings.ToUpper(method) == httpMethod {
+			return true
+		}
+	}
+	return false
+}
+
+// usedVariables lists the variables used in a step, builtin variables are excluded.
+func usedVariables(step model.Step) []string {
+	fields := []string{step.URL, step.Body, step.BodyFile, readTextFile(step.BodyFile)}
+	var headerKeys []string
+	for key := range step.Headers {
+		headerKeys = append(headerKeys, key)
+	}
+	sort.Strings(headerKeys)
+	for _, key := range headerKeys {
+		fields = append(fields, step.Headers[key]...)
+	}
+	for _, key := range sortedKeys(step.Form) {
+		fields = append(fields, step.Form[key])
+	}
+	if step.Multipart != nil {
+		for _, key := range sortedKeys(step.Multipart.Fields) {
+			fields = append(fields, step.Multipart.Fields[key])
+		}
+		for _, file := range step.Multipart.Files {
+			fields = append(fields, file.Path)
+		}
+	}
+	for _, assertion := range step.Assertions {
+		fields = append(fields, assertion.Value, assertion.ValueFile, readTextFile(assertion.ValueFile))
+	}
+	if step.Retry != nil {
+		for _, assertion := range step.Retry.Until {
+			fields = append(fields, assertion.Value, assertion.ValueFile, readTextFile(assertion.ValueFile))
+		}
+	}
+	fields = append(fields, authFields(step.Auth)...)
+	return fieldVariables(fields)
+}
+
+// fieldVariables lists the variables used in the fields, builtin variables are excluded.
+func fieldVariables(fields []string) []string {
+	var result []string
+	alreadyAdded := map[string]bool{}
+	emptyContext := context.NewContext()
+	for _, field := range fields {
+		for _, subMatch := range variableRegex.FindAllStringSubmatch(field, -1) {
+			name := subMatch[1]
+			if alreadyAdded[name] || emptyContext.Patch(subMatch[0]) != subMatch[0] {
+				continue
+			}
+			alreadyAdded[name] = true
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// authFields lists the values of the authentication which can contain variables.
+func authFields(auth *model.Auth) []string {
+	if auth == nil {
+		return nil
+	}
+	var fields []string
+	for _, credentials := range []*model.Credentials{auth.Basic, auth.Digest} {
+		if credentials != nil {
+			fields = append(fields, credentials.Username, credentials.Password)
+		}
+	}
+	if auth.APIKey != nil {
+		fields = append(fields, auth.APIKey.Name, auth.APIKey.Value)
+	}
+	if auth.AWS != nil {
+		fields = append(fields, auth.AWS.AccessKeyID, auth.AWS.SecretAccessKey, auth.AWS.SessionToken,
+			auth.AWS.Region, auth.AWS.Service)
+	}
+	if auth.OAuth2 != nil {
+		fields = append(fields, auth.OAuth2.TokenURL, auth.OAuth2.ClientID, auth.OAuth2.ClientSecret,
+			auth.OAuth2.Username, auth.OAuth2.Password)
+		fields = append(fields, auth.OAuth2.Scopes...)
+	}
+	return fields
+}
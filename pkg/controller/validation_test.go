@@ -0,0 +1,320 @@
+package controller_test
+
+import (
+	"testing"
+
+	"github.com/thomaspoignant/api-scenario/pkg/controller"
+	"github.com/thomaspoignant/api-scenario/pkg/model"
+	"github.com/thomaspoignant/api-scenario/test"
+)
+
+func validationMessages(errs []controller.ValidationError) []string {
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	return messages
+}
+
+func TestValidateScenarioValid(t *testing.T) {
+	scenario := model.Scenario{
+		Steps: []model.Step{
+			{StepType: model.Pause, Duration: 1},
+			{
+				StepType: model.RequestStep,
+				URL:      "{{baseUrl}}/users?time={{timestamp}}",
+				Method:   "GET",
+				Assertions: []model.Assertion{
+					{Source: model.ResponseStatus, Comparison: model.EqualNumber, Value: "200"},
+					{Source: model.ResponseJson, Comparison: model.HasKey, Property: "data", Value: "id"},
+				},
+				Variables: []model.Variable{
+					{Source: model.ResponseJson, Property: "data[0].id", Name: "user_id"},
+				},
+			},
+			{
+				StepType: model.RequestStep,
+				URL:      "{{baseUrl}}/users/{{user_id}}",
+				Method:   "post",
+				Body:     `{"hash":"{{md5({{user_id}})}}"}`,
+			},
+		},
+	}
+
+	got := controller.ValidateScenario("scenario.yml", scenario, []string{"baseUrl"})
+	test.Equals(t, "Scenario should be valid", []string(nil), validationMessages(got))
+}
+
+func TestValidateScenarioInvalid(t *testing.T) {
+	scenario := model.Scenario{
+		Steps: []model.Step{
+			{StepType: model.Pause},
+			{
+				StepType: model.RequestStep,
+				Method:   "FETCH",
+				Headers:  map[string][]string{"Authorization": {"Bearer {{token}}"}},
+				Assertions: []model.Assertion{
+					{Source: model.ResponseStatus, Comparison: model.Contains, Value: "20"},
+					{Source: model.ResponseHeader, Comparison: model.HasKey},
+					{Source: model.ResponseJson, Comparison: model.Equal, Value: "{{user_id}}"},
+					{Source: model.ResponseJson, Comparison: model.MatchesSchema, Value: `{"type": `},
+					{Source: model.ResponseText, Comparison: model.MatchesRegex, Value: `[a-z`},
+					{Source: model.ResponseJson, Comparison: model.NotEmpty, Property: "$.items[?(@.id == )]"},
+					{Source: model.ResponseXml, Comparison: model.NotEmpty, Property: "/soap:Envelope/ns:Body"},
+					{Source: model.ResponseHtml, Comparison: model.NotEmpty, Property: "form input[name=csrf@value"},
+					{Source: model.ResponseHtml, Comparison: model.NotEmpty},
+					{Source: model.ResponseTLS, Comparison: model.NotEmpty, Property: "expiry"},
+				},
+				Namespaces: map[string]string{"soap": "http://schemas.xmlsoap.org/soap/envelope/"},
+				Variables: []model.Variable{
+					{Source: model.ResponseJson, Property: "id", Name: "user_id"},
+					{Source: model.Source(42), Name: "invalid"},
+					{Source: model.ResponseJson, Name: "json_regex", Regex: `id=(\d+)`},
+					{Source: model.ResponseText, Name: "missing_group", Regex: `id=(\d+)`, Group: "id"},
+					{Source: model.ResponseTLS, Property: "issuer_name", Name: "issuer"},
+				},
+			},
+			{StepType: model.StepType(42)},
+		},
+	}
+
+	want := []string{
+		"scenario.yml: step #1: a pause step should have a duration greater than 0",
+		"scenario.yml: step #2: a request step should have an url",
+		"scenario.yml: step #2: \"FETCH\" is not a valid HTTP method",
+		"scenario.yml: step #2: assertion #1: the comparison contains is not supported for the source response_status",
+		"scenario.yml: step #2: assertion #2: a property is needed for the source response_header",
+		"scenario.yml: step #2: assertion #4: the value of matches_schema should be a JSON schema or a schema file",
+		"scenario.yml: step #2: assertion #5: \"[a-z\" is not a valid regex",
+		"scenario.yml: step #2: assertion #6: invalid JSONPath \"$.items[?(@.id == )]\": wrong request: wrong request: @.id == ",
+		"scenario.yml: step #2: assertion #7: invalid XPath \"/soap:Envelope/ns:Body\": prefix ns not defined.",
+		"scenario.yml: step #2: assertion #8: invalid CSS selector \"form input[name=csrf\": unexpected EOF in attribute selector",
+		"scenario.yml: step #2: assertion #9: a property is needed for the source response_html",
+		"scenario.yml: step #2: assertion #10: unknown TLS property \"expiry\", the properties are protocol_version, cipher_suite, server_name, subject, issuer, sans, not_after, days_until_expiry",
+		"scenario.yml: step #2: variable \"invalid\": 42 is an invalid source",
+		"scenario.yml: step #2: variable \"json_regex\": a regex can only be used with response_text and response_header",
+		"scenario.yml: step #2: variable \"missing_group\": the regex \"id=(\\\\d+)\" has no group named \"id\"",
+		"scenario.yml: step #2: variable \"issuer\": unknown TLS property \"issuer_name\", the properties are protocol_version, cipher_suite, server_name, subject, issuer, sans, not_after, days_until_expiry",
+		"scenario.yml: step #2: the variable {{token}} is used but never defined before",
+		"scenario.yml: step #2: the variable {{user_id}} is used but never defined before",
+		"scenario.yml: step #3: 42 is an invalid step_type",
+	}
+	got := controller.ValidateScenario("scenario.yml", scenario, nil)
+	test.Equals(t, "Should list every error", want, validationMessages(got))
+}
+
+func TestValidateScenarioEmpty(t *testing.T) {
+	got := controller.ValidateScenario("scenario.yml", model.Scenario{}, nil)
+	test.Equals(t, "Should have an error", []string{"scenario.yml: the scenario has no step"}, validationMessages(got))
+}
+
+func TestIsComparisonSupported(t *testing.T) {
+	test.Equals(t, "equal_number on status", true, controller.IsComparisonSupported(model.ResponseStatus, model.EqualNumber))
+	test.Equals(t, "contains on status", false, controller.IsComparisonSupported(model.ResponseStatus, model.Contains))
+	test.Equals(t, "has_key on header", true, controller.IsComparisonSupported(model.ResponseHeader, model.HasKey))
+	test.Equals(t, "has_key on text", false, controller.IsComparisonSupported(model.ResponseText, model.HasKey))
+	test.Equals(t, "has_value on json", true, controller.IsComparisonSupported(model.ResponseJson, model.HasValue))
+	test.Equals(t, "matches_schema on json", true, controller.IsComparisonSupported(model.ResponseJson, model.MatchesSchema))
+	test.Equals(t, "matches_schema on xml", false, controller.IsComparisonSupported(model.ResponseXml, model.MatchesSchema))
+}
+
+func TestValidateScenarioBody(t *testing.T) {
+	scenario := model.Scenario{
+		Steps: []model.Step{
+			{
+				StepType: model.RequestStep,
+				URL:      "http://test.com",
+				Body:     "{}",
+				Form:     map[string]string{"name": "{{name}}"},
+				Multipart: &model.Multipart{
+					Files: []model.MultipartFile{
+						{Field: "avatar", Path: "../../testdata/upload/avatar.png"},
+						{Field: "cv", Path: "../../testdata/upload/cv.pdf"},
+						{Path: "{{dir}}/avatar.png"},
+					},
+				},
+			},
+		},
+	}
+
+	want := []string{
+		"scenario.yml: step #1: only one of body, body_file, form and multipart can be used",
+		"scenario.yml: step #1: multipart: the file \"../../testdata/upload/cv.pdf\" does not exist",
+		"scenario.yml: step #1: multipart: a file should have a field",
+		"scenario.yml: step #1: the variable {{name}} is used but never defined before",
+		"scenario.yml: step #1: the variable {{dir}} is used but never defined before",
+	}
+	got := controller.ValidateScenario("scenario.yml", scenario, nil)
+	test.Equals(t, "Should validate the body", want, validationMessages(got))
+}
+
+func TestValidateScenarioFiles(t *testing.T) {
+	scenario := model.Scenario{
+		Steps: []model.Step{
+			{
+				StepType: model.RequestStep,
+				URL:      "http://test.com",
+				BodyFile: "../../testdata/upload/user.json",
+				Assertions: []model.Assertion{
+					{Source: model.ResponseText, Comparison: model.Equal, ValueFile: "../../testdata/upload/welcome.txt"},
+					{Source: model.ResponseText, Comparison: model.Equal, Value: "ok", ValueFile: "../../testdata/upload/unknown.txt"},
+				},
+			},
+			{
+				StepType: model.RequestStep,
+				URL:      "http://test.com",
+				BodyFile: "../../testdata/upload",
+			},
+		},
+	}
+
+	want := []string{
+		"scenario.yml: step #1: assertion #2: only one of value and value_file can be used",
+		"scenario.yml: step #1: assertion #2: the value file \"../../testdata/upload/unknown.txt\" does not exist",
+		"scenario.yml: step #1: the variable {{name}} is used but never defined before",
+		"scenario.yml: step #2: the body file \"../../testdata/upload\" does not exist",
+	}
+	got := controller.ValidateScenario("scenario.yml", scenario, nil)
+	test.Equals(t, "Should validate the files", want, validationMessages(got))
+}
+
+func TestValidateScenarioRequiredVariables(t *testing.T) {
+	scenario := model.Scenario{
+		RequiredVariables: []string{"baseUrl"},
+		Steps: []model.Step{
+			{StepType: model.RequestStep, URL: "{{baseUrl}}/users/{{id}}"},
+		},
+	}
+
+	want := []string{"scenario.yml: step #1: the variable {{id}} is used but never defined before"}
+	got := controller.ValidateScenario("scenario.yml", scenario, nil)
+	test.Equals(t, "Should consider the required variables as defined", want, validationMessages(got))
+}
+
+func TestValidateScenarioAuth(t *testing.T) {
+	scenario := model.Scenario{
+		Auth: &model.Auth{
+			Type:   model.AuthOAuth2,
+			OAuth2: &model.OAuth2{GrantType: model.PasswordGrant, ClientID: "{{client_id}}", Username: "john"},
+		},
+		Steps: []model.Step{
+			{StepType: model.RequestStep, URL: "http://test.com"},
+		},
+	}
+
+	want := []string{
+		"scenario.yml: auth: oauth2: a token_url is needed",
+		"scenario.yml: auth: oauth2: a username and a password are needed for the password grant",
+	}
+	got := controller.ValidateScenario("scenario.yml", scenario, []string{"client_id"})
+	test.Equals(t, "Should validate the auth", want, validationMessages(got))
+
+	scenario.Auth.OAuth2 = nil
+	got = controller.ValidateScenario("scenario.yml", scenario, nil)
+	test.Equals(t, "Should need the oauth2 settings", []string{"scenario.yml: auth: the oauth2 settings are missing"}, validationMessages(got))
+}
+
+func TestValidateScenarioAuthVariables(t *testing.T) {
+	scenario := model.Scenario{
+		Variables: map[string]string{"username": "john"},
+		Auth: &model.Auth{
+			Type:  model.AuthBasic,
+			Basic: &model.Credentials{Username: "{{username}}", Password: "{{password}}"},
+		},
+		Steps: []model.Step{
+			{
+				StepType:  model.RequestStep,
+				URL:       "http://test.com",
+				Variables: []model.Variable{{Source: model.ResponseJson, Property: "token", Name: "token"}},
+			},
+		},
+	}
+
+	want := []string{"scenario.yml: auth: the variable {{password}} is used but never defined before"}
+	got := controller.ValidateScenario("scenario.yml", scenario, nil)
+	test.Equals(t, "Should check the variables of the auth", want, validationMessages(got))
+
+	got = controller.ValidateScenario("scenario.yml", scenario, []string{"password"})
+	test.Equals(t, "Should use the known variables", 0, len(got))
+}
+
+func TestValidateScenarioTLS(t *testing.T) {
+	scenario := model.Scenario{
+		TLS: &model.TLSConfig{CACert: "../../testdata/tls/client.pem", ClientCert: "../../testdata/tls/unknown.pem"},
+		Steps: []model.Step{
+			{StepType: model.RequestStep, URL: "https://test.com"},
+		},
+	}
+
+	want := []string{
+		"scenario.yml: tls: the client_cert and the client_key should be set together",
+		`scenario.yml: tls: the client_cert file "../../testdata/tls/unknown.pem" does not exist`,
+	}
+	got := controller.ValidateScenario("scenario.yml", scenario, nil)
+	test.Equals(t, "Should validate the TLS settings", want, validationMessages(got))
+}
+
+func TestValidateStepAuth(t *testing.T) {
+	scenario := model.Scenario{
+		Steps: []model.Step{
+			{
+				StepType: model.RequestStep,
+				URL:      "http://test.com",
+				Auth:     &model.Auth{Type: model.AuthBasic, Basic: &model.Credentials{Password: "{{password}}"}},
+			},
+			{
+				StepType: model.RequestStep,
+				URL:      "http://test.com",
+				Auth:     &model.Auth{Type: model.AuthAPIKey, APIKey: &model.APIKey{Name: "X-Key", In: 2}},
+			},
+			{
+				StepType: model.RequestStep,
+				URL:      "http://test.com",
+				Auth:     &model.Auth{Type: model.AuthDigest},
+			},
+			{
+				StepType: model.RequestStep,
+				URL:      "http://test.com",
+				Auth:     &model.Auth{Type: model.AuthAWSSigV4, AWS: &model.AWSSigV4{Region: "eu-west-1"}},
+			},
+		},
+	}
+
+	want := []string{
+		"scenario.yml: step #1: auth: basic: a username is needed",
+		"scenario.yml: step #1: the variable {{password}} is used but never defined before",
+		"scenario.yml: step #2: auth: api_key: 2 is an invalid location",
+		"scenario.yml: step #2: auth: api_key: a name and a value are needed",
+		"scenario.yml: step #3: auth: the digest settings are missing",
+		"scenario.yml: step #4: auth: aws_sigv4: a service is needed",
+	}
+	got := controller.ValidateScenario("scenario.yml", scenario, nil)
+	test.Equals(t, "Should validate the auth of the steps", want, validationMessages(got))
+}
+
+func TestValidateScenarioRetry(t *testing.T) {
+	scenario := model.Scenario{
+		Steps: []model.Step{
+			{
+				StepType: model.RequestStep,
+				URL:      "http://test.com",
+				Retry: &model.Retry{
+					MaxAttempts: 0,
+					Interval:    -1,
+					Until: []model.Assertion{
+						{Source: model.ResponseTime, Comparison: model.HasKey, Value: "{{job_id}}"},
+					},
+				},
+			},
+		},
+	}
+
+	want := []string{
+		"scenario.yml: step #1: retry: max_attempts should be greater than 0",
+		"scenario.yml: step #1: retry: interval and backoff should be positive",
+		"scenario.yml: step #1: retry until #1: the comparison has_key is not supported for the source response_time",
+		"scenario.yml: step #1: the variable {{job_id}} is used but never defined before",
+	}
+	got := controller.ValidateScenario("scenario.yml", scenario, nil)
+	test.Equals(t, "Should validate the retry block", want, validationMessages(got))
+}
@@ -8,3 +8,8 @@ func InitializeScenarioController() (ScenarioController, error) {
 	wire.Build(NewScenarioController, NewRestClient, NewStepController, NewAssertionController)
 	return &scenarioControllerImpl{}, nil
 }
+
+func InitializeSuiteController() (SuiteController, error) {
+	wire.Build(NewSuiteController, NewScenarioController, NewRestClient, NewStepController, NewAssertionController)
+	return &suiteControllerImpl{}, nil
+}
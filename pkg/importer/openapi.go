@@ -0,0 +1,435 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/thomaspoignant/api-scenario/pkg/model"
+)
+
+// GroupBy defines how the operations of an OpenAPI document are grouped in scenarios.
+type GroupBy string
+
+const (
+	GroupByTag  GroupBy = "tag"
+	GroupByPath GroupBy = "path"
+)
+
+// openapiMethods are the operations of a path item in the order we want them in the scenario.
+var openapiMethods = []string{"get", "post", "put", "patch", "delete", "head", "options", "trace"}
+
+// maxSchemaDepth limits the example generation for recursive schemas.
+const maxSchemaDepth = 6
+
+var pathParamRegex = regexp.MustCompile(`{([^{}]+)}`)
+
+// GenerateFromOpenAPI creates skeleton scenarios from an OpenAPI 3 document (JSON or YAML).
+// Every operation becomes a request step with an example body and assertions on the documented success codes.
+func GenerateFromOpenAPI(content []byte, groupBy GroupBy) ([]ImportedScenario, []string, error) {
+	var document map[string]interface{}
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, nil, fmt.Errorf("impossible to read the OpenAPI document: %v", err)
+	}
+
+	version, _ := document["openapi"].(string)
+	if !strings.HasPrefix(version, "3.") {
+		return nil, nil, fmt.Errorf("only OpenAPI 3 documents are supported (openapi: %q)", version)
+	}
+
+	g := openapiGenerator{document: document}
+	return g.generate(groupBy), g.warnings, nil
+}
+
+type openapiGenerator struct {
+	document map[string]interface{}
+	warnings []string
+}
+
+// generate is creating the scenarios from the paths of the document.
+func (g *openapiGenerator) generate(groupBy GroupBy) []ImportedScenario {
+	info := asMap(g.document["info"])
+	title := asString(info["title"])
+	if len(title) == 0 {
+		title = "OpenAPI"
+	}
+
+	variables := map[string]string{"baseUrl": g.serverURL()}
+	paths := asMap(g.document["paths"])
+	scenarios := map[string]*model.Scenario{}
+	var groups []string
+	for _, path := range sortedKeys(paths) {
+		pathItem := g.resolve(paths[path])
+		for _, method := range openapiMethods {
+			operation, ok := pathItem[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			group := path
+			if groupBy != GroupByPath {
+				group = "default"
+				if tags, ok := operation["tags"].([]interface{}); ok && len(tags) > 0 {
+					group = asString(tags[0])
+				}
+			}
+
+			scenario, exists := scenarios[group]
+			if !exists {
+				scenario = &model.Scenario{
+					Name:        fmt.Sprintf("%s - %s", title, group),
+					Version:     infoVersion(info["version"]),
+					Description: fmt.Sprintf("Scenario generated from the OpenAPI specification of %s", title),
+					Variables:   map[string]string{},
+				}
+				for key, value := range variables {
+					scenario.Variables[key] = value
+				}
+				scenarios[group] = scenario
+				groups = append(groups, group)
+			}
+
+			parameters := g.mergeParameters(asSlice(pathItem["parameters"]), asSlice(operation["parameters"]))
+			scenario.Steps = append(scenario.Steps, g.convertOperation(path, method, operation, parameters, scenario))
+		}
+	}
+
+	// groups with the same slug (e.g. the tags "Pets" and "pets") are saved in different files
+	var result []ImportedScenario
+	usedPaths := map[string]bool{}
+	for _, group := range groups {
+		base := slugify(title) + "/" + slugify(group)
+		path := base + ".yml"
+		for i := 2; usedPaths[path]; i++ {
+			path = fmt.Sprintf("%s-%d.yml", base, i)
+		}
+		usedPaths[path] = true
+		result = append(result, ImportedScenario{
+			Path:     path,
+			Scenario: *scenarios[group],
+		})
+	}
+	return result
+}
+
+// mergeParameters returns the parameters of the path and of the operation,
+// a parameter of the operation overrides the parameter of the path with the same name and location.
+func (g *openapiGenerator) mergeParameters(pathParameters []interface{}, operationParameters []interface{}) []map[string]interface{} {
+	var result []map[string]interface{}
+	indexes := map[string]int{}
+	for _, param := range append(append([]interface{}{}, pathParameters...), operationParameters...) {
+		parameter := g.resolve(param)
+		if parameter == nil {
+			continue
+		}
+		key := asString(parameter["in"]) + "\x00" + asString(parameter["name"])
+		if index, exists := indexes[key]; exists {
+			result[index] = parameter
+			continue
+		}
+		indexes[key] = len(result)
+		result = append(result, parameter)
+	}
+	return result
+}
+
+// infoVersion returns the version of the document, a YAML version like 2 is read as a number.
+func infoVersion(node interface{}) string {
+	if node == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", node)
+}
+
+// convertOperation creates a request step for an operation.
+// The examples of the parameters are added in the default variables of the scenario,
+// the parameters used by the step without example are added in its required variables.
+func (g *openapiGenerator) convertOperation(path string, method string, operation map[string]interface{},
+	parameters []map[string]interface{}, scenario *model.Scenario) model.Step {
+	step := model.Step{
+		StepType: model.RequestStep,
+		Method:   strings.ToUpper(method),
+		URL:      "{{baseUrl}}" + pathParamRegex.ReplaceAllString(path, "{{$1}}"),
+	}
+
+	var queryParams []string
+	for _, parameter := range parameters {
+		name := asString(parameter["name"])
+		if len(name) == 0 {
+			continue
+		}
+		if example, ok := g.parameterExample(parameter); ok {
+			if _, exists := scenario.Variables[name]; !exists {
+				scenario.Variables[name] = example
+			}
+		}
+
+		required, _ := parameter["required"].(bool)
+		used := false
+		switch asString(parameter["in"]) {
+		case "path":
+			used = true
+		case "query":
+			if required {
+				queryParams = append(queryParams, fmt.Sprintf("%s={{%s}}", name, name))
+				used = true
+			}
+		case "header":
+			if required {
+				if step.Headers == nil {
+					step.Headers = map[string][]string{}
+				}
+				step.Headers[name] = []string{"{{" + name + "}}"}
+				used = true
+			}
+		}
+		if _, exists := scenario.Variables[name]; used && !exists && !containsString(scenario.RequiredVariables, name) {
+			scenario.RequiredVariables = append(scenario.RequiredVariables, name)
+		}
+	}
+	if len(queryParams) > 0 {
+		step.URL += "?" + strings.Join(queryParams, "&")
+	}
+
+	if requestBody := g.resolve(operation["requestBody"]); requestBody != nil {
+		contentType, body := g.requestBodyExample(requestBody)
+		if len(contentType) > 0 {
+			if step.Headers == nil {
+				step.Headers = map[string][]string{}
+			}
+			step.Headers["Content-Type"] = []string{contentType}
+			step.Body = body
+		}
+	}
+
+	step.Assertions = successAssertions(asMap(operation["responses"]))
+	if len(step.Assertions) == 0 {
+		g.warnings = append(g.warnings, fmt.Sprintf("%s %s: no success response documented", strings.ToUpper(method), path))
+	}
+	return step
+}
+
+// parameterExample returns the example value of a parameter if there is one.
+func (g *openapiGenerator) parameterExample(parameter map[string]interface{}) (string, bool) {
+	if example, ok := parameter["example"]; ok {
+		return fmt.Sprintf("%v", example), true
+	}
+	schema := g.resolve(parameter["schema"])
+	for _, key := range []string{"example", "default"} {
+		if example, ok := schema[key]; ok {
+			return fmt.Sprintf("%v", example), true
+		}
+	}
+	return "", false
+}
+
+// requestBodyExample creates an example body for the request, JSON content is preferred.
+func (g *openapiGenerator) requestBodyExample(requestBody map[string]interface{}) (string, string) {
+	content := asMap(requestBody["content"])
+	if len(content) == 0 {
+		return "", ""
+	}
+
+	mediaTypes := sortedKeys(content)
+	contentType := ""
+	for _, mediaType := range mediaTypes {
+		if strings.Contains(mediaType, "json") {
+			contentType = mediaType
+			break
+		}
+	}
+	if len(contentType) == 0 {
+		contentType = mediaTypes[0]
+		g.warnings = append(g.warnings, fmt.Sprintf("content type %s is not JSON, the body is empty", contentType))
+		return contentType, ""
+	}
+
+	mediaTypeObject := asMap(content[contentType])
+	example, ok := mediaTypeObject["example"]
+	if !ok {
+		examples := asMap(mediaTypeObject["examples"])
+		if names := sortedKeys(examples); len(names) > 0 {
+			example, ok = g.resolve(examples[names[0]])["value"]
+		}
+	}
+	if !ok {
+		example = g.schemaExample(mediaTypeObject["schema"], 0)
+	}
+
+	body, err := json.Marshal(example)
+	if err != nil {
+		return contentType, ""
+	}
+	return contentType, string(body)
+}
+
+// schemaExample generates an example value from a JSON schema.
+func (g *openapiGenerator) schemaExample(node interface{}, depth int) interface{} {
+	schema := g.resolve(node)
+	if schema == nil || depth > maxSchemaDepth {
+		return nil
+	}
+
+	for _, key := range []string{"example", "default"} {
+		if example, ok := schema[key]; ok {
+			return example
+		}
+	}
+	if enum := asSlice(schema["enum"]); len(enum) > 0 {
+		return enum[0]
+	}
+	if allOf := asSlice(schema["allOf"]); len(allOf) > 0 {
+		merged := map[string]interface{}{}
+		for _, subSchema := range allOf {
+			if object, ok := g.schemaExample(subSchema, depth+1).(map[string]interface{}); ok {
+				for key, value := range object {
+					merged[key] = value
+				}
+			}
+		}
+		return merged
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if choices := asSlice(schema[key]); len(choices) > 0 {
+			return g.schemaExample(choices[0], depth+1)
+		}
+	}
+
+	schemaType := asString(schema["type"])
+	if len(schemaType) == 0 && schema["properties"] != nil {
+		schemaType = "object"
+	}
+
+	switch schemaType {
+	case "object":
+		object := map[string]interface{}{}
+		for name, property := range asMap(schema["properties"]) {
+			object[name] = g.schemaExample(property, depth+1)
+		}
+		return object
+	case "array":
+		return []interface{}{g.schemaExample(schema["items"], depth+1)}
+	case "integer", "number":
+		return 0
+	case "boolean":
+		return true
+	case "string":
+		switch asString(schema["format"]) {
+		case "date-time":
+			return "2020-01-01T00:00:00Z"
+		case "date":
+			return "2020-01-01"
+		case "email":
+			return "user@example.com"
+		case "uuid":
+			return "{{uuid}}"
+		case "uri":
+			return "https://example.com"
+		default:
+			return "string"
+		}
+	default:
+		return nil
+	}
+}
+
+// successAssertions creates the assertions on the status code for the documented success responses.
+func successAssertions(responses map[string]interface{}) []model.Assertion {
+	var codes []string
+	hasRange := false
+	for code := range responses {
+		if !strings.HasPrefix(code, "2") {
+			continue
+		}
+		if _, err := strconv.Atoi(code); err != nil {
+			hasRange = true
+		}
+		codes = append(codes, code)
+	}
+
+	switch {
+	case len(codes) == 0:
+		return nil
+	case len(codes) == 1 && !hasRange:
+		return []model.Assertion{{Source: model.ResponseStatus, Comparison: model.EqualNumber, Value: codes[0]}}
+	default:
+		return []model.Assertion{
+			{Source: model.ResponseStatus, Comparison: model.IsGreaterThanOrEqual, Value: "200"},
+			{Source: model.ResponseStatus, Comparison: model.IsLessThan, Value: "300"},
+		}
+	}
+}
+
+// serverURL returns the URL of the first server with its variables replaced by their default values.
+func (g *openapiGenerator) serverURL() string {
+	servers := asSlice(g.document["servers"])
+	if len(servers) == 0 {
+		return "http://localhost"
+	}
+	server := asMap(servers[0])
+	url := asString(server["url"])
+	for name, variable := range asMap(server["variables"]) {
+		url = strings.ReplaceAll(url, "{"+name+"}", fmt.Sprintf("%v", asMap(variable)["default"]))
+	}
+	return strings.TrimSuffix(url, "/")
+}
+
+// resolve follows the local $ref of a node and returns it as a map.
+func (g *openapiGenerator) resolve(node interface{}) map[string]interface{} {
+	object := asMap(node)
+	for i := 0; i < maxSchemaDepth && object != nil; i++ {
+		ref, ok := object["$ref"].(string)
+		if !ok {
+			return object
+		}
+		if !strings.HasPrefix(ref, "#/") {
+			g.warnings = append(g.warnings, fmt.Sprintf("external reference %s is not supported", ref))
+			return nil
+		}
+
+		var current interface{} = g.document
+		for _, element := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			element = strings.ReplaceAll(strings.ReplaceAll(element, "~1", "/"), "~0", "~")
+			current = asMap(current)[element]
+		}
+		object = asMap(current)
+	}
+	return object
+}
+
+func containsString(values []string, value string) bool {
+	for _, current := range values {
+		if current == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func asMap(node interface{}) map[string]interface{} {
+	object, _ := node.(map[string]interface{})
+	return object
+}
+
+func asSlice(node interface{}) []interface{} {
+	slice, _ := node.([]interface{})
+	return slice
+}
+
+func asString(node interface{}) string {
+	s, _ := node.(string)
+	return s
+}
@@ -0,0 +1,142 @@
+package importer_test
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/thomaspoignant/api-scenario/pkg/importer"
+	"github.com/thomaspoignant/api-scenario/pkg/model"
+	"github.com/thomaspoignant/api-scenario/test"
+)
+
+func TestGenerateFromOpenAPI(t *testing.T) {
+	spec, err := ioutil.ReadFile("../../testdata/openapi.yaml")
+	test.Ok(t, err)
+
+	got, warnings, err := importer.GenerateFromOpenAPI(spec, importer.GroupByTag)
+	test.Ok(t, err)
+
+	statusRange := []model.Assertion{
+		{Source: model.ResponseStatus, Comparison: model.IsGreaterThanOrEqual, Value: "200"},
+		{Source: model.ResponseStatus, Comparison: model.IsLessThan, Value: "300"},
+	}
+	want := []importer.ImportedScenario{
+		{
+			Path: "pet-store/pets.yml",
+			Scenario: model.Scenario{
+				Name:              "Pet Store - pets",
+				Version:           "1.0.0",
+				Description:       "Scenario generated from the OpenAPI specification of Pet Store",
+				Variables:         map[string]string{"baseUrl": "https://api.example.com/v1", "limit": "10", "X-Request-Id": "abc"},
+				RequiredVariables: []string{"petId"},
+				Steps: []model.Step{
+					{
+						StepType:   model.RequestStep,
+						Method:     "GET",
+						URL:        "{{baseUrl}}/pets?limit={{limit}}",
+						Assertions: []model.Assertion{{Source: model.ResponseStatus, Comparison: model.EqualNumber, Value: "200"}},
+					},
+					{
+						StepType: model.RequestStep,
+						Method:   "POST",
+						URL:      "{{baseUrl}}/pets",
+						Headers: map[string][]string{
+							"X-Request-Id": {"{{X-Request-Id}}"},
+							"Content-Type": {"application/json"},
+						},
+						Body:       `{"birth":"2020-01-01","id":"{{uuid}}","name":"rex","status":"available","tags":["string"]}`,
+						Assertions: []model.Assertion{{Source: model.ResponseStatus, Comparison: model.EqualNumber, Value: "201"}},
+					},
+					{
+						StepType:   model.RequestStep,
+						Method:     "GET",
+						URL:        "{{baseUrl}}/pets/{{petId}}",
+						Assertions: statusRange,
+					},
+				},
+			},
+		},
+		{
+			Path: "pet-store/default.yml",
+			Scenario: model.Scenario{
+				Name:              "Pet Store - default",
+				Version:           "1.0.0",
+				Description:       "Scenario generated from the OpenAPI specification of Pet Store",
+				Variables:         map[string]string{"baseUrl": "https://api.example.com/v1"},
+				RequiredVariables: []string{"petId"},
+				Steps: []model.Step{
+					{StepType: model.RequestStep, Method: "DELETE", URL: "{{baseUrl}}/pets/{{petId}}"},
+				},
+			},
+		},
+	}
+	test.Equals(t, "generated scenarios", want, got)
+	test.Equals(t, "warnings", []string{"DELETE /pets/{petId}: no success response documented"}, warnings)
+}
+
+func TestGenerateFromOpenAPIGroupByPath(t *testing.T) {
+	spec, err := ioutil.ReadFile("../../testdata/openapi.yaml")
+	test.Ok(t, err)
+
+	got, _, err := importer.GenerateFromOpenAPI(spec, importer.GroupByPath)
+	test.Ok(t, err)
+	test.Equals(t, "number of scenarios", 2, len(got))
+	test.Equals(t, "first path", "pet-store/pets.yml", got[0].Path)
+	test.Equals(t, "second path", "pet-store/pets-petid.yml", got[1].Path)
+	test.Equals(t, "steps of the second path", 2, len(got[1].Scenario.Steps))
+}
+
+func TestGenerateFromOpenAPIInvalidVersion(t *testing.T) {
+	_, _, err := importer.GenerateFromOpenAPI([]byte("swagger: \"2.0\""), importer.GroupByTag)
+	test.Ko(t, err)
+}
+
+func TestGenerateFromOpenAPIParameterPrecedence(t *testing.T) {
+	spec := []byte(`
+openapi: 3.0.0
+info:
+  title: Users
+  version: 2
+paths:
+  /users/{id}:
+    parameters:
+      - {name: id, in: path, required: true, schema: {type: string}}
+      - {name: page, in: query, required: true, example: 1}
+    get:
+      parameters:
+        - {name: id, in: path, required: true, example: "42"}
+        - {name: page, in: query, required: false}
+      responses:
+        "200": {description: ok}
+`)
+
+	got, _, err := importer.GenerateFromOpenAPI(spec, importer.GroupByTag)
+	test.Ok(t, err)
+	test.Equals(t, "Should keep a numeric version", "2", got[0].Scenario.Version)
+	test.Equals(t, "The operation parameter should override the path one",
+		"{{baseUrl}}/users/{{id}}", got[0].Scenario.Steps[0].URL)
+	test.Equals(t, "Should use the example of the operation parameter",
+		map[string]string{"baseUrl": "http://localhost", "id": "42"}, got[0].Scenario.Variables)
+	test.Equals(t, "Should not require a parameter with an example", 0, len(got[0].Scenario.RequiredVariables))
+}
+
+func TestGenerateFromOpenAPICollidingFiles(t *testing.T) {
+	spec := []byte(`
+openapi: 3.0.0
+info: {title: Pets, version: 1.0.0}
+paths:
+  /pets:
+    get:
+      tags: [Pets]
+      responses: {"200": {description: ok}}
+    post:
+      tags: [pets]
+      responses: {"201": {description: ok}}
+`)
+
+	got, _, err := importer.GenerateFromOpenAPI(spec, importer.GroupByTag)
+	test.Ok(t, err)
+	test.Equals(t, "number of scenarios", 2, len(got))
+	test.Equals(t, "first path", "pets/pets.yml", got[0].Path)
+	test.Equals(t, "second path should have a suffix", "pets/pets-2.yml", got[1].Path)
+}
@@ -0,0 +1,415 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/thomaspoignant/api-scenario/pkg/model"
+)
+
+// ImportedScenario is a scenario created from an import and the relative path of the file where to save it.
+type ImportedScenario struct {
+	Path     string
+	Scenario model.Scenario
+}
+
+// postmanCollection is a Postman collection in format v2.1.
+type postmanCollection struct {
+	Info struct {
+		Name        string      `json:"name"`
+		Description interface{} `json:"description"`
+	} `json:"info"`
+	Item     []postmanItem     `json:"item"`
+	Variable []postmanVariable `json:"variable"`
+}
+
+// postmanItem is either a folder (with items) or a request.
+type postmanItem struct {
+	Name    string          `json:"name"`
+	Item    []postmanItem   `json:"item"`
+	Request json.RawMessage `json:"request"`
+	Event   []postmanEvent  `json:"event"`
+}
+
+type postmanRequest struct {
+	Method string          `json:"method"`
+	Header []postmanHeader `json:"header"`
+	Body   *postmanBody    `json:"body"`
+	URL    json.RawMessage `json:"url"`
+}
+
+type postmanHeader struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled"`
+}
+
+type postmanBody struct {
+	Mode string `json:"mode"`
+	Raw  string `json:"raw"`
+}
+
+type postmanEvent struct {
+	Listen string `json:"listen"`
+	Script struct {
+		Exec json.RawMessage `json:"exec"`
+	} `json:"script"`
+}
+
+type postmanVariable struct {
+	Key      string      `json:"key"`
+	Value    interface{} `json:"value"`
+	Disabled bool        `json:"disabled"`
+}
+
+// postmanEnvironment is a Postman environment export.
+type postmanEnvironment struct {
+	Values []struct {
+		Key     string      `json:"key"`
+		Value   interface{} `json:"value"`
+		Enabled *bool       `json:"enabled"`
+	} `json:"values"`
+}
+
+// postmanDynamicVariables are the Postman dynamic variables with an equivalent builtin.
+var postmanDynamicVariables = map[string]string{
+	"{{$guid}}":               "{{uuid}}",
+	"{{$randomUUID}}":         "{{uuid}}",
+	"{{$timestamp}}":          "{{timestamp}}",
+	"{{$isoTimestamp}}":       "{{utc_datetime}}",
+	"{{$randomInt}}":          "{{random_int(0,1000)}}",
+	"{{$randomAlphaNumeric}}": "{{random_string(1)}}",
+}
+
+var (
+	jsonDataRegex      = regexp.MustCompile(`(?:var|let|const)\s+(\w+)\s*=\s*pm\.response\.json\(\)`)
+	statusRegex        = regexp.MustCompile(`pm\.response\.to\.have\.status\((\d+)\)`)
+	statusExpectRegex  = regexp.MustCompile(`pm\.expect\(pm\.response\.code\)\.to\.(?:eql|equal|be\.equal)\((\d+)\)`)
+	responseTimeRegex  = regexp.MustCompile(`pm\.expect\(pm\.response\.responseTime\)\.to\.be\.(below|above)\((\d+)\)`)
+	headerRegex        = regexp.MustCompile(`pm\.response\.to\.have\.header\(\s*["']([^"']+)["']\s*(?:,\s*["']([^"']*)["']\s*)?\)`)
+	bodyIncludeRegex   = regexp.MustCompile(`pm\.expect\(pm\.response\.text\(\)\)\.to\.include\(\s*["']([^"']*)["']\s*\)`)
+	jsonExpectRegex    = regexp.MustCompile(`pm\.expect\(([\w.\[\]]+)\)\.to\.(eql|equal|be\.equal|include|be\.above|be\.below|exist|not\.exist|be\.null|be\.empty|not\.be\.empty)\b(?:\(\s*("(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'|[^()"']*?)\s*\))?`)
+	setVariableRegex   = regexp.MustCompile(`pm\.(?:environment|collectionVariables|globals|variables)\.set\(\s*["']([^"']+)["']\s*,\s*([\w.\[\]]+)\s*\)`)
+	responseJsonPrefix = "pm.response.json()"
+)
+
+// ImportPostman converts a Postman v2.1 collection into scenarios.
+// Every folder containing requests becomes a scenario, the collection and environment variables are added as
+// default variables of every scenario. It returns also warnings for every element impossible to convert.
+func ImportPostman(collectionContent []byte, environmentContent []byte) ([]ImportedScenario, []string, error) {
+	var collection postmanCollection
+	if err := json.Unmarshal(collectionContent, &collection); err != nil {
+		return nil, nil, fmt.Errorf("impossible to read the Postman collection: %v", err)
+	}
+
+	variables := map[string]string{}
+	for _, variable := range collection.Variable {
+		if !variable.Disabled {
+			variables[variable.Key] = fmt.Sprintf("%v", variable.Value)
+		}
+	}
+
+	if len(environmentContent) > 0 {
+		var environment postmanEnvironment
+		if err := json.Unmarshal(environmentContent, &environment); err != nil {
+			return nil, nil, fmt.Errorf("impossible to read the Postman environment: %v", err)
+		}
+		for _, value := range environment.Values {
+			if value.Enabled == nil || *value.Enabled {
+				variables[value.Key] = fmt.Sprintf("%v", value.Value)
+			}
+		}
+	}
+
+	importer := postmanImporter{collectionName: collection.Info.Name, variables: variables, usedPaths: map[string]bool{}}
+	if description, ok := collection.Info.Description.(string); ok {
+		importer.description = description
+	}
+	importer.importFolder(nil, collection.Item)
+	return importer.scenarios, importer.warnings, nil
+}
+
+type postmanImporter struct {
+	collectionName string
+	description    string
+	variables      map[string]string
+	scenarios      []ImportedScenario
+	warnings       []string
+	usedPaths      map[string]bool
+}
+
+// importFolder creates a scenario with the requests of the folder and imports the sub-folders.
+func (p *postmanImporter) importFolder(folders []string, items []postmanItem) {
+	name := p.collectionName
+	if len(folders) > 0 {
+		name = strings.Join(append([]string{p.collectionName}, folders...), " / ")
+	}
+
+	// keep the position of the scenario to have the folder before its sub-folders
+	position := len(p.scenarios)
+	scenario := model.Scenario{Name: name, Version: "1.0", Description: p.description}
+	for _, item := range items {
+		if item.Request == nil {
+			p.importFolder(append(append([]string{}, folders...), item.Name), item.Item)
+			continue
+		}
+
+		step, err := p.convertRequest(item)
+		if err != nil {
+			p.warnings = append(p.warnings, fmt.Sprintf("%s: request %q ignored: %v", name, item.Name, err))
+			continue
+		}
+		scenario.Steps = append(scenario.Steps, step)
+	}
+
+	if len(scenario.Steps) == 0 {
+		return
+	}
+	if len(p.variables) > 0 {
+		scenario.Variables = p.variables
+	}
+
+	var pathElements []string
+	for _, folder := range append([]string{p.collectionName}, folders...) {
+		pathElements = append(pathElements, slugify(folder))
+	}
+	// folders with the same slug (e.g. "Users" and "users") are saved in different files
+	base := filepath.Join(pathElements...)
+	path := base + ".yml"
+	for i := 2; p.usedPaths[path]; i++ {
+		path = fmt.Sprintf("%s-%d.yml", base, i)
+	}
+	p.usedPaths[path] = true
+	imported := ImportedScenario{
+		Path:     path,
+		Scenario: scenario,
+	}
+	p.scenarios = append(p.scenarios[:position], append([]ImportedScenario{imported}, p.scenarios[position:]...)...)
+}
+
+// convertRequest converts a Postman request into a request step.
+func (p *postmanImporter) convertRequest(item postmanItem) (model.Step, error) {
+	var request postmanRequest
+	var rawURL string
+	if err := json.Unmarshal(item.Request, &rawURL); err != nil {
+		if err := json.Unmarshal(item.Request, &request); err != nil {
+			return model.Step{}, err
+		}
+		rawURL = convertPostmanUrl(request.URL)
+	}
+
+	if len(rawURL) == 0 {
+		return model.Step{}, fmt.Errorf("no url")
+	}
+
+	method := request.Method
+	if len(method) == 0 {
+		method = "GET"
+	}
+	step := model.Step{
+		StepType: model.RequestStep,
+		URL:      convertDynamicVariables(rawURL),
+		Method:   strings.ToUpper(method),
+	}
+
+	for _, header := range request.Header {
+		if header.Disabled {
+			continue
+		}
+		if step.Headers == nil {
+			step.Headers = map[string][]string{}
+		}
+		step.Headers[header.Key] = append(step.Headers[header.Key], convertDynamicVariables(header.Value))
+	}
+
+	if request.Body != nil && len(request.Body.Mode) > 0 {
+		if request.Body.Mode == "raw" {
+			step.Body = convertDynamicVariables(request.Body.Raw)
+		} else {
+			p.warnings = append(p.warnings, fmt.Sprintf("%s: body mode %q is not supported", item.Name, request.Body.Mode))
+		}
+	}
+
+	for _, event := range item.Event {
+		if event.Listen != "test" {
+			continue
+		}
+		assertions, variables := p.convertTestScript(item.Name, scriptLines(event.Script.Exec))
+		step.Assertions = append(step.Assertions, assertions...)
+		step.Variables = append(step.Variables, variables...)
+	}
+	return step, nil
+}
+
+// convertTestScript converts the simple pm.test of a script into assertions and the pm.*.set into variables.
+func (p *postmanImporter) convertTestScript(requestName string, lines []string) ([]model.Assertion, []model.Variable) {
+	var assertions []model.Assertion
+	var variables []model.Variable
+
+	// Names of the JS variables containing the JSON response
+	jsonNames := []string{responseJsonPrefix}
+	for _, line := range lines {
+		for _, match := range jsonDataRegex.FindAllStringSubmatch(line, -1) {
+			jsonNames = append(jsonNames, match[1])
+		}
+	}
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !strings.Contains(trimmed, "pm.expect") && !strings.Contains(trimmed, "pm.response.to") &&
+			!strings.Contains(trimmed, ".set(") {
+			continue
+		}
+
+		switch {
+		case statusRegex.MatchString(trimmed):
+			value := statusRegex.FindStringSubmatch(trimmed)[1]
+			assertions = append(assertions, model.Assertion{Source: model.ResponseStatus, Comparison: model.EqualNumber, Value: value})
+
+		case statusExpectRegex.MatchString(trimmed):
+			value := statusExpectRegex.FindStringSubmatch(trimmed)[1]
+			assertions = append(assertions, model.Assertion{Source: model.ResponseStatus, Comparison: model.EqualNumber, Value: value})
+
+		case responseTimeRegex.MatchString(trimmed):
+			match := responseTimeRegex.FindStringSubmatch(trimmed)
+			comparison := model.IsLessThan
+			if match[1] == "above" {
+				comparison = model.IsGreaterThan
+			}
+			// Postman response time is in ms and api-scenario in seconds
+			ms, _ := strconv.Atoi(match[2])
+			value := strconv.FormatFloat(float64(ms)/1000, 'f', -1, 64)
+			assertions = append(assertions, model.Assertion{Source: model.ResponseTime, Comparison: comparison, Value: value})
+
+		case headerRegex.MatchString(trimmed):
+			match := headerRegex.FindStringSubmatch(trimmed)
+			if len(match[2]) > 0 {
+				assertions = append(assertions, model.Assertion{Source: model.ResponseHeader, Property: match[1], Comparison: model.Equal, Value: match[2]})
+			} else {
+				assertions = append(assertions, model.Assertion{Source: model.ResponseHeader, Property: match[1], Comparison: model.HasKey})
+			}
+
+		case bodyIncludeRegex.MatchString(trimmed):
+			value := bodyIncludeRegex.FindStringSubmatch(trimmed)[1]
+			assertions = append(assertions, model.Assertion{Source: model.ResponseText, Comparison: model.Contains, Value: value})
+
+		case jsonExpectRegex.MatchString(trimmed):
+			match := jsonExpectRegex.FindStringSubmatch(trimmed)
+			property, ok := jsonProperty(match[1], jsonNames)
+			if !ok {
+				p.warnings = append(p.warnings, fmt.Sprintf("%s: test not converted: %s", requestName, trimmed))
+				continue
+			}
+			assertions = append(assertions, convertJsonExpect(property, match[2], unquote(match[3])))
+
+		case setVariableRegex.MatchString(trimmed):
+			match := setVariableRegex.FindStringSubmatch(trimmed)
+			property, ok := jsonProperty(match[2], jsonNames)
+			if !ok {
+				p.warnings = append(p.warnings, fmt.Sprintf("%s: variable not converted: %s", requestName, trimmed))
+				continue
+			}
+			variables = append(variables, model.Variable{Source: model.ResponseJson, Property: property, Name: match[1]})
+
+		default:
+			p.warnings = append(p.warnings, fmt.Sprintf("%s: test not converted: %s", requestName, trimmed))
+		}
+	}
+	return assertions, variables
+}
+
+// convertJsonExpect creates the assertion for a chai expectation on a JSON property.
+func convertJsonExpect(property string, expectation string, value string) model.Assertion {
+	assertion := model.Assertion{Source: model.ResponseJson, Property: property, Value: value}
+	switch expectation {
+	case "include":
+		assertion.Comparison = model.Contains
+	case "be.above":
+		assertion.Comparison = model.IsGreaterThan
+	case "be.below":
+		assertion.Comparison = model.IsLessThan
+	case "exist", "not.be.empty":
+		assertion.Comparison = model.NotEmpty
+	case "be.empty":
+		assertion.Comparison = model.Empty
+	case "not.exist", "be.null":
+		assertion.Comparison = model.IsNull
+	default:
+		assertion.Comparison = model.Equal
+	}
+	return assertion
+}
+
+// jsonProperty extracts the property path from a JS expression using the JSON response.
+func jsonProperty(expression string, jsonNames []string) (string, bool) {
+	for _, name := range jsonNames {
+		if strings.HasPrefix(expression, name+".") {
+			return strings.TrimPrefix(expression, name+"."), true
+		}
+		if strings.HasPrefix(expression, name+"[") {
+			return strings.TrimPrefix(expression, name), true
+		}
+	}
+	return "", false
+}
+
+// convertPostmanUrl reads the URL of a request, it could be a string or an object.
+func convertPostmanUrl(rawMessage json.RawMessage) string {
+	var rawURL string
+	if err := json.Unmarshal(rawMessage, &rawURL); err == nil {
+		return rawURL
+	}
+
+	var url struct {
+		Raw string `json:"raw"`
+	}
+	if err := json.Unmarshal(rawMessage, &url); err != nil {
+		return ""
+	}
+	return url.Raw
+}
+
+// scriptLines reads the exec field of a script, it could be a string or an array of strings.
+func scriptLines(exec json.RawMessage) []string {
+	var lines []string
+	if err := json.Unmarshal(exec, &lines); err == nil {
+		return lines
+	}
+	var script string
+	if err := json.Unmarshal(exec, &script); err == nil {
+		return strings.Split(script, "\n")
+	}
+	return nil
+}
+
+// convertDynamicVariables replaces the Postman dynamic variables by api-scenario builtins.
+func convertDynamicVariables(s string) string {
+	for postmanVariable, builtin := range postmanDynamicVariables {
+		s = strings.ReplaceAll(s, postmanVariable, builtin)
+	}
+	return s
+}
+
+// unquote removes the quotes around a JS string.
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+var slugRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slugify converts a name to something usable as a file name.
+func slugify(name string) string {
+	slug := strings.Trim(slugRegex.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(slug) == 0 {
+		return "scenario"
+	}
+	return slug
+}
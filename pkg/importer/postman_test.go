@@ -0,0 +1,133 @@
+package importer_test
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/thomaspoignant/api-scenario/pkg/importer"
+	"github.com/thomaspoignant/api-scenario/pkg/model"
+	"github.com/thomaspoignant/api-scenario/test"
+)
+
+func TestImportPostman(t *testing.T) {
+	collection, err := ioutil.ReadFile("../../testdata/postman_collection.json")
+	test.Ok(t, err)
+	environment := []byte(`{"values":[{"key":"baseUrl","value":"http://localhost:8080","enabled":true},{"key":"token","value":"abc","enabled":false}]}`)
+
+	got, warnings, err := importer.ImportPostman(collection, environment)
+	test.Ok(t, err)
+
+	variables := map[string]string{"baseUrl": "http://localhost:8080"}
+	want := []importer.ImportedScenario{
+		{
+			Path: "users-api.yml",
+			Scenario: model.Scenario{
+				Name:        "Users API",
+				Version:     "1.0",
+				Description: "Tests of the users API",
+				Variables:   variables,
+				Steps: []model.Step{
+					{StepType: model.RequestStep, URL: "{{baseUrl}}/health", Method: "GET"},
+				},
+			},
+		},
+		{
+			Path: "users-api/users.yml",
+			Scenario: model.Scenario{
+				Name:        "Users API / Users",
+				Version:     "1.0",
+				Description: "Tests of the users API",
+				Variables:   variables,
+				Steps: []model.Step{
+					{
+						StepType: model.RequestStep,
+						URL:      "{{baseUrl}}/api/users",
+						Method:   "POST",
+						Headers:  map[string][]string{"Content-Type": {"application/json"}},
+						Body:     `{"name":"john","id":"{{uuid}}"}`,
+						Assertions: []model.Assertion{
+							{Source: model.ResponseStatus, Comparison: model.EqualNumber, Value: "201"},
+							{Source: model.ResponseJson, Property: "name", Comparison: model.Equal, Value: "john"},
+							{Source: model.ResponseJson, Property: "data[0].id", Comparison: model.IsGreaterThan, Value: "0"},
+							{Source: model.ResponseTime, Comparison: model.IsLessThan, Value: "0.5"},
+							{Source: model.ResponseHeader, Property: "Content-Type", Comparison: model.HasKey},
+							{Source: model.ResponseText, Comparison: model.Contains, Value: "john"},
+						},
+						Variables: []model.Variable{
+							{Source: model.ResponseJson, Property: "id", Name: "user_id"},
+						},
+					},
+				},
+			},
+		},
+		{
+			Path: "users-api/users/admin.yml",
+			Scenario: model.Scenario{
+				Name:        "Users API / Users / Admin",
+				Version:     "1.0",
+				Description: "Tests of the users API",
+				Variables:   variables,
+				Steps: []model.Step{
+					{StepType: model.RequestStep, URL: "{{baseUrl}}/api/admin/upload", Method: "POST"},
+				},
+			},
+		},
+	}
+	test.Equals(t, "Scenarios should be imported", want, got)
+
+	wantWarnings := []string{
+		"Create user: test not converted: pm.expect(someFunction()).to.be.true;",
+		"Upload: body mode \"formdata\" is not supported",
+	}
+	test.Equals(t, "Should warn about elements not converted", wantWarnings, warnings)
+}
+
+func TestImportPostmanInvalid(t *testing.T) {
+	_, _, err := importer.ImportPostman([]byte(`{"item": "invalid"}`), nil)
+	test.Ko(t, err)
+
+	_, _, err = importer.ImportPostman([]byte(`{"item": []}`), []byte("invalid"))
+	test.Ko(t, err)
+}
+
+func TestImportPostmanSingleLineTest(t *testing.T) {
+	collection := []byte(`{
+  "info": {"name": "Users"},
+  "item": [{
+    "name": "Get user",
+    "request": {"method": "GET", "url": "http://test.com/users/1"},
+    "event": [{"listen": "test", "script": {"exec": [
+      "var jsonData = pm.response.json();",
+      "pm.test(\"name\", function () { pm.expect(jsonData.name).to.eql(\"John (admin)\"); });",
+      "pm.test(\"age\", function () { pm.expect(jsonData.age).to.be.above(18); });"
+    ]}}]
+  }]
+}`)
+
+	got, warnings, err := importer.ImportPostman(collection, nil)
+	test.Ok(t, err)
+	test.Equals(t, "Should not have warnings", 0, len(warnings))
+	want := []model.Assertion{
+		{Source: model.ResponseJson, Property: "name", Comparison: model.Equal, Value: "John (admin)"},
+		{Source: model.ResponseJson, Property: "age", Comparison: model.IsGreaterThan, Value: "18"},
+	}
+	test.Equals(t, "Should convert the one-line tests", want, got[0].Scenario.Steps[0].Assertions)
+}
+
+func TestImportPostmanSameFolderSlug(t *testing.T) {
+	collection := []byte(`{
+  "info": {"name": "Users"},
+  "item": [
+    {"name": "Users", "item": [{"name": "List", "request": {"method": "GET", "url": "http://test.com/users"}}]},
+    {"name": "users", "item": [{"name": "Get", "request": {"method": "GET", "url": "http://test.com/users/1"}}]},
+    {"name": "Users", "item": [{"name": "Delete", "request": {"method": "DELETE", "url": "http://test.com/users/1"}}]}
+  ]
+}`)
+
+	got, _, err := importer.ImportPostman(collection, nil)
+	test.Ok(t, err)
+	test.Equals(t, "should import every folder", 3, len(got))
+	test.Equals(t, "first path", "users/users.yml", got[0].Path)
+	test.Equals(t, "second path", "users/users-2.yml", got[1].Path)
+	test.Equals(t, "third path", "users/users-3.yml", got[2].Path)
+}
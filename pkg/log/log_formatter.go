@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"github.com/fatih/color"
 	"github.com/sirupsen/logrus"
+	"sync"
 )
 
 var SuccessColor = color.New(color.FgGreen)
 var errorColor = color.New(color.FgRed)
+var disableColorsOnce sync.Once
 
 type OutputFormatter struct {
 	DisableColors bool
@@ -16,8 +18,11 @@ type OutputFormatter struct {
 // Format is used by logrus to print logs in stdout. This formatter is basic and just print the message.
 func (f *OutputFormatter) Format(entry *logrus.Entry) ([]byte, error) {
 	if f.DisableColors {
-		SuccessColor.DisableColor()
-		errorColor.DisableColor()
+		// colors are shared by every logger, we disable them only once to be safe with concurrent loggers.
+		disableColorsOnce.Do(func() {
+			SuccessColor.DisableColor()
+			errorColor.DisableColor()
+		})
 	}
 
 	if entry.Level <= logrus.ErrorLevel {
@@ -3,7 +3,8 @@ package model
 // An Assertions defines one expected result
 type Assertion struct {
 	Comparison Comparison `json:"comparison"`
-	Value      string     `json:"value"`
-	Source     Source     `json:"Source"`
+	Value      string     `json:"value,omitempty"`
+	ValueFile  string     `json:"value_file,omitempty"` // file with the expected value, relative to the scenario file
+	Source     Source     `json:"source"`
 	Property   string     `json:"property,omitempty"`
 }
@@ -0,0 +1,85 @@
+package model
+
+// AuthType is the authentication scheme used for the requests.
+type AuthType int
+
+//go:generate enumer -type=AuthType -json -linecomment  -output authtype_gen.go
+const (
+	AuthNone     AuthType = iota //none
+	AuthOAuth2                   //oauth2
+	AuthBasic                    //basic
+	AuthDigest                   //digest
+	AuthAPIKey                   //api_key
+	AuthAWSSigV4                 //aws_sigv4
+)
+
+// OAuth2GrantType is the grant used to get an OAuth2 access token.
+type OAuth2GrantType int
+
+//go:generate enumer -type=OAuth2GrantType -json -linecomment  -output oauth2granttype_gen.go
+const (
+	ClientCredentialsGrant OAuth2GrantType = iota //client_credentials
+	PasswordGrant                                 //password
+)
+
+// APIKeyLocation is where the API key is sent in the request.
+type APIKeyLocation int
+
+//go:generate enumer -type=APIKeyLocation -json -linecomment  -output apikeylocation_gen.go
+const (
+	APIKeyInHeader APIKeyLocation = iota //header
+	APIKeyInQuery                        //query
+)
+
+// Auth is the authentication of the requests, it can be set for a step, for a scenario or from the command line.
+// Only the settings of the type are used.
+type Auth struct {
+	Type   AuthType     `json:"type"`
+	OAuth2 *OAuth2      `json:"oauth2,omitempty"`
+	Basic  *Credentials `json:"basic,omitempty"`
+	Digest *Credentials `json:"digest,omitempty"`
+	APIKey *APIKey      `json:"api_key,omitempty"`
+	AWS    *AWSSigV4    `json:"aws_sigv4,omitempty"`
+}
+
+// Credentials are the username and the password of the basic and digest authentications.
+// The values can contain variables.
+type Credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password,omitempty"`
+}
+
+// AWSSigV4 signs the requests with the AWS Signature Version 4.
+// The values can contain variables, an empty value is read from the AWS environment variables
+// (AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN and AWS_REGION or AWS_DEFAULT_REGION).
+type AWSSigV4 struct {
+	AccessKeyID     string `json:"access_key_id,omitempty"`
+	SecretAccessKey string `json:"secret_access_key,omitempty"`
+	SessionToken    string `json:"session_token,omitempty"`
+	Region          string `json:"region,omitempty"`
+	Service         string `json:"service"`
+}
+
+// APIKey is a key sent in a header or in a query parameter.
+// The values can contain variables.
+type APIKey struct {
+	Name  string         `json:"name"`
+	Value string         `json:"value"`
+	In    APIKeyLocation `json:"in,omitempty"`
+}
+
+// OAuth2 configures how to get an access token from the token endpoint of an OAuth2 server.
+// The values can contain variables.
+type OAuth2 struct {
+	GrantType    OAuth2GrantType `json:"grant_type,omitempty"`
+	TokenURL     string          `json:"token_url"`
+	ClientID     string          `json:"client_id"`
+	ClientSecret string          `json:"client_secret,omitempty"`
+	Username     string          `json:"username,omitempty"`
+	Password     string          `json:"password,omitempty"`
+	Scopes       []string        `json:"scopes,omitempty"`
+
+	// CredentialsInBody sends the client credentials in the body of the token request
+	// instead of the Authorization header.
+	CredentialsInBody bool `json:"credentials_in_body,omitempty"`
+}
@@ -19,6 +19,9 @@ const (
 	IsNull                                 //is_null
 	HasValue                               //has_value
 	HasKey                                 //has_key
+	MatchesSchema                          //matches_schema
+	MatchesRegex                           //matches_regex
+	DoesNotMatchRegex                      //does_not_match_regex
 )
 
 type comparisonMessage struct {
@@ -72,6 +75,15 @@ var messages = map[Comparison]comparisonMessage{
 	HasKey: {
 		Success: "'%v' key does exist",
 		Failure: "'%v' key does not exist"},
+	MatchesSchema: {
+		Success: "'%v' did match the JSON schema",
+		Failure: "'%v' did not match the JSON schema: %s"},
+	MatchesRegex: {
+		Success: "'%v' did match the regex %s",
+		Failure: "'%v' did not match the regex %s"},
+	DoesNotMatchRegex: {
+		Success: "'%v' did not match the regex %s",
+		Failure: "'%v' did match the regex %s"},
 }
 
 func (i Comparison) GetMessage() comparisonMessage {
@@ -0,0 +1,11 @@
+package model
+
+// CookieMode defines how a request step uses the cookie jar of the scenario.
+type CookieMode int
+
+//go:generate enumer -type=CookieMode -json -linecomment  -output cookiemode_gen.go
+const (
+	CookiesEnabled  CookieMode = iota //enabled
+	CookiesClear                      //clear
+	CookiesDisabled                   //disabled
+)
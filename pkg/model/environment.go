@@ -0,0 +1,104 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/ghodss/yaml"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Environment contains the variables and the default headers used to run the scenarios against an environment.
+// Secrets are the names of the variables to mask in the outputs.
+type Environment struct {
+	Variables map[string]string `json:"variables,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Secrets   []string          `json:"secrets,omitempty"`
+}
+
+// LoadEnvironmentFiles reads the environment files, a variable or a header of a file overrides
+// the ones of the previous files.
+// The files can be in YAML, JSON or dotenv (only variables) format, depending on their extension.
+func LoadEnvironmentFiles(files []string) (Environment, error) {
+	result := Environment{Variables: map[string]string{}, Headers: map[string]string{}}
+	for _, file := range files {
+		env, err := loadEnvironmentFile(file)
+		if err != nil {
+			return Environment{}, err
+		}
+		for key, value := range env.Variables {
+			result.Variables[key] = value
+		}
+		for key, value := range env.Headers {
+			result.Headers[key] = value
+		}
+		result.Secrets = append(result.Secrets, env.Secrets...)
+	}
+	return result, nil
+}
+
+func loadEnvironmentFile(file string) (Environment, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return Environment{}, fmt.Errorf("Impossible to locate the environment file: %s\n Error: %v", file, err)
+	}
+
+	var env Environment
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yml", ".yaml":
+		err = yaml.Unmarshal(content, &env)
+	case ".json":
+		err = json.Unmarshal(content, &env)
+	default:
+		env.Variables, err = parseDotEnv(content)
+	}
+	if err != nil {
+		return Environment{}, fmt.Errorf("Impossible to read the environment file: %s\n%v", file, err)
+	}
+	return env, nil
+}
+
+// parseDotEnv reads the variables of a dotenv file (KEY=value lines).
+// The values can be quoted, the escape sequences are interpreted in the double quoted values
+// and a # starts a comment in the values without quotes.
+func parseDotEnv(content []byte) (map[string]string, error) {
+	variables := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		separator := strings.Index(line, "=")
+		if separator <= 0 {
+			return nil, fmt.Errorf("line %d: the format should be KEY=value", lineNumber)
+		}
+		key := strings.TrimSpace(line[:separator])
+		value := strings.TrimSpace(line[separator+1:])
+
+		switch {
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid value %s", lineNumber, value)
+			}
+			value = unquoted
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		default:
+			if index := strings.Index(value, " #"); index >= 0 {
+				value = strings.TrimSpace(value[:index])
+			}
+		}
+		variables[key] = value
+	}
+	return variables, scanner.Err()
+}
@@ -0,0 +1,46 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/thomaspoignant/api-scenario/pkg/model"
+	"github.com/thomaspoignant/api-scenario/test"
+)
+
+func TestLoadEnvironmentFiles(t *testing.T) {
+	got, err := model.LoadEnvironmentFiles([]string{
+		"../../testdata/env/common.yml",
+		"../../testdata/env/staging.json",
+		"../../testdata/env/secrets.env",
+	})
+	test.Ok(t, err)
+
+	want := model.Environment{
+		Variables: map[string]string{
+			"baseUrl":  "https://staging.example.com",
+			"timeout":  "30",
+			"TOKEN":    "abc123",
+			"PASSWORD": `p@ss "word"`,
+			"USERNAME": "john doe",
+			"ORG":      "acme",
+		},
+		Headers: map[string]string{
+			"Accept": "application/json",
+			"X-Env":  "staging",
+		},
+		Secrets: []string{"ORG"},
+	}
+	test.Equals(t, "Should merge the environment files", want, got)
+}
+
+func TestLoadEnvironmentFilesInvalid(t *testing.T) {
+	_, err := model.LoadEnvironmentFiles([]string{"../../testdata/env/unknown.yml"})
+	test.Ko(t, err)
+
+	_, err = model.LoadEnvironmentFiles([]string{"../../testdata/scenario_invalid_json.json"})
+	test.Ko(t, err)
+
+	_, err = model.LoadEnvironmentFiles([]string{"../../testdata/directory/readme.txt"})
+	test.Equals(t, "Should fail on an invalid dotenv file",
+		"Impossible to read the environment file: ../../testdata/directory/readme.txt\nline 1: the format should be KEY=value", err.Error())
+}
@@ -1,25 +1,66 @@
 package model
 
 import (
+	"crypto/tls"
 	"net/http"
 	"time"
 
 	"github.com/sendgrid/rest"
+	"github.com/thomaspoignant/api-scenario/pkg/secret"
 )
 
 type Response struct {
-	TimeElapsed time.Duration `json:"time_elapsed,omitempty"` // e.g 1ms
-	StatusCode  int           `json:"status_code,omitempty"`  // e.g. 200
-	Body        string        `json:"body,omitempty"`         // e.g. {"result: Success"}
-	Header      http.Header   `json:"header,omitempty"`       // e.g. map[X-Ratelimit-Limit:[600]]
+	TimeElapsed time.Duration  `json:"time_elapsed,omitempty"` // e.g 1ms
+	StatusCode  int            `json:"status_code,omitempty"`  // e.g. 200
+	Body        string         `json:"body,omitempty"`         // e.g. {"result: Success"}
+	Header      http.Header    `json:"header,omitempty"`       // e.g. map[X-Ratelimit-Limit:[600]]
+	Cookies     []*http.Cookie `json:"cookies,omitempty"`      // cookies set by the response
+	TLS         *TLSInfo       `json:"tls,omitempty"`          // TLS connection, nil for plain HTTP
 }
 
-// Create a new responseApi from a rest.Response
-func NewResponse(restResponse rest.Response, timeElapsed time.Duration) (Response, error) {
+// Create a new responseApi from a rest.Response, tlsState is the state of the TLS connection (nil for plain HTTP).
+func NewResponse(restResponse rest.Response, tlsState *tls.ConnectionState, timeElapsed time.Duration) (Response, error) {
 	return Response{
 		Body:        restResponse.Body,
 		StatusCode:  restResponse.StatusCode,
 		TimeElapsed: timeElapsed,
 		Header:      restResponse.Headers,
+		Cookies:     readSetCookies(restResponse.Headers),
+		TLS:         NewTLSInfo(tlsState),
 	}, nil
 }
+
+// readSetCookies parses the Set-Cookie headers of a response.
+func readSetCookies(header http.Header) []*http.Cookie {
+	if len(header.Values("Set-Cookie")) == 0 {
+		return nil
+	}
+	return (&http.Response{Header: header}).Cookies()
+}
+
+// Masked returns a copy of the response where the secrets are masked.
+// The values of the sensitive headers and of the cookies are fully masked.
+func (response Response) Masked() Response {
+	registry := secret.GetRegistry()
+	response.Body = registry.Mask(response.Body)
+
+	if response.Header != nil {
+		header := make(http.Header, len(response.Header))
+		for key, values := range response.Header {
+			for _, value := range values {
+				header[key] = append(header[key], registry.MaskHeader(key, value))
+			}
+		}
+		response.Header = header
+	}
+
+	cookies := response.Cookies
+	response.Cookies = nil
+	for _, cookie := range cookies {
+		masked := *cookie
+		masked.Value = secret.Placeholder
+		masked.Raw = ""
+		response.Cookies = append(response.Cookies, &masked)
+	}
+	return response
+}
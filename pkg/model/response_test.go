@@ -19,7 +19,7 @@ func TestNewResponseCreateAValidResponse(t *testing.T) {
 	}
 
 	expectedDuration := time.Duration(1 * time.Second)
-	response, err := model.NewResponse(restResp, expectedDuration)
+	response, err := model.NewResponse(restResp, nil, expectedDuration)
 	test.Ok(t, err)
 	test.Equals(t, "Invalid duration", expectedDuration, response.TimeElapsed)
 	test.Equals(t, "Invalid status code", restResp.StatusCode, response.StatusCode)
@@ -37,9 +37,26 @@ func TestNewResponseEmptyBody(t *testing.T) {
 	}
 
 	expectedDuration := time.Duration(1 * time.Second)
-	response, err := model.NewResponse(restResp, expectedDuration)
+	response, err := model.NewResponse(restResp, nil, expectedDuration)
 	test.Ok(t, err)
 	test.Equals(t, "Invalid duration", expectedDuration, response.TimeElapsed)
 	test.Equals(t, "Invalid status code", restResp.StatusCode, response.StatusCode)
 	test.Equals(t, "Body should be an empty map", 0, len(response.Body))
 }
+
+func TestNewResponseCookies(t *testing.T) {
+	restResp := rest.Response{
+		StatusCode: 200,
+		Headers: map[string][]string{
+			"Set-Cookie": {"session=abc; Path=/; Domain=test.com; HttpOnly; Secure", "lang=fr"},
+		},
+	}
+
+	response, err := model.NewResponse(restResp, nil, time.Second)
+	test.Ok(t, err)
+	test.Equals(t, "Should have the cookies", 2, len(response.Cookies))
+	test.Equals(t, "Invalid cookie value", "abc", response.Cookies[0].Value)
+	test.Equals(t, "Invalid cookie domain", "test.com", response.Cookies[0].Domain)
+	test.Equals(t, "Invalid cookie flag", true, response.Cookies[0].HttpOnly)
+	test.Equals(t, "Invalid cookie name", "lang", response.Cookies[1].Name)
+}
@@ -1,10 +1,12 @@
 package model
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/sirupsen/logrus"
 	"github.com/thomaspoignant/api-scenario/pkg/log"
+	"github.com/thomaspoignant/api-scenario/pkg/secret"
 )
 
 type ResultAssertion struct {
@@ -15,6 +17,15 @@ type ResultAssertion struct {
 	Message  string `json:"message,omitempty"`
 }
 
+// MarshalJSON writes the error as its message, encoding/json writes an error interface as an empty object.
+func (ra ResultAssertion) MarshalJSON() ([]byte, error) {
+	type resultAssertion ResultAssertion
+	return json.Marshal(struct {
+		resultAssertion
+		Err string `json:"error,omitempty"`
+	}{resultAssertion(ra), errorMessage(ra.Err)})
+}
+
 func NewResultAssertion(comparison Comparison, success bool, v ...interface{}) ResultAssertion {
 	msg := comparison.GetMessage()
 	var message string
@@ -35,21 +46,35 @@ var sourceDisplayName = map[Source]string{
 	ResponseTime:   "Response time",
 	ResponseStatus: "status",
 	ResponseHeader: "header",
+	ResponseXml:    "body",
+	ResponseHtml:   "body",
+	ResponseCookie: "cookie",
+	ResponseTLS:    "tls",
+}
+
+// Masked returns a copy of the assertion result where the secrets are masked.
+func (ar ResultAssertion) Masked() ResultAssertion {
+	ar.Message = secret.GetRegistry().Mask(ar.Message)
+	ar.Err = maskError(ar.Err)
+	return ar
 }
 
-func (ar *ResultAssertion) Print() {
+// Print is logging the result of the assertion, the secrets are masked.
+func (ar *ResultAssertion) Print(logger *logrus.Logger) {
+	masked := ar.Masked()
+	ar = &masked
 	source := sourceDisplayName[ar.Source]
 	if len(ar.Property) > 0 {
 		source += "." + ar.Property
 	}
 
 	if ar.Success {
-		logrus.Infof(log.SuccessColor.Sprint("\u2713\t")+"%s - %s", source, ar.Message)
+		logger.Infof(log.SuccessColor.Sprint("\u2713\t")+"%s - %s", source, ar.Message)
 		return
 	}
 
-	logrus.Errorf("X\t%s - %s", source, ar.Message)
-	if logrus.IsLevelEnabled(logrus.DebugLevel) && ar.Err != nil {
-		logrus.Debug(ar.Err)
+	logger.Errorf("X\t%s - %s", source, ar.Message)
+	if logger.IsLevelEnabled(logrus.DebugLevel) && ar.Err != nil {
+		logger.Debug(ar.Err)
 	}
 }
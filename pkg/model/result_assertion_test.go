@@ -2,6 +2,7 @@ package model_test
 
 import (
 	"fmt"
+	"github.com/sirupsen/logrus"
 	"github.com/thomaspoignant/api-scenario/pkg/model"
 	"github.com/thomaspoignant/api-scenario/test"
 	"testing"
@@ -11,7 +12,7 @@ func TestPrintResultAssertionSuccess(t *testing.T) {
 	test.SetupLog()
 	want := "✓\tstatus - '1' was not equal to 20\n"
 	res := model.NewResultAssertion(model.NotEqual, true, "1", "20")
-	got := test.CaptureOutput(res.Print)
+	got := test.CaptureOutput(func() { res.Print(logrus.StandardLogger()) })
 	test.Equals(t, "", want, got)
 }
 
@@ -21,6 +22,16 @@ func TestPrintResultAssertionWithError(t *testing.T) {
 	res := model.NewResultAssertion(model.NotEqual, false, "1", "1")
 	res.Property = "email"
 	res.Err = fmt.Errorf("random error")
-	got := test.CaptureOutput(res.Print)
+	got := test.CaptureOutput(func() { res.Print(logrus.StandardLogger()) })
+	test.Equals(t, "", want, got)
+}
+
+func TestPrintResultAssertionSourceDisplayName(t *testing.T) {
+	test.SetupLog()
+	want := "✓\ttls.issuer - 'CN=Test CA' was equal to CN=Test CA\n"
+	res := model.NewResultAssertion(model.Equal, true, "CN=Test CA", "CN=Test CA")
+	res.Source = model.ResponseTLS
+	res.Property = "issuer"
+	got := test.CaptureOutput(func() { res.Print(logrus.StandardLogger()) })
 	test.Equals(t, "", want, got)
 }
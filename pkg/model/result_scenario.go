@@ -1,9 +1,12 @@
 package model
 
+import "time"
+
 type ScenarioResult struct {
 	Name        string       `json:"name,omitempty"`
 	Version     string       `json:"version,omitempty"`
 	Description string       `json:"description,omitempty"`
+	File        string       `json:"file,omitempty"`
 	StepResults []ResultStep `json:"step_results,omitempty"`
 }
 
@@ -16,3 +19,22 @@ func (scenario *ScenarioResult) IsSuccess() bool {
 	}
 	return true
 }
+
+// Duration is the total time spent running the steps of the scenario.
+func (scenario *ScenarioResult) Duration() time.Duration {
+	var duration time.Duration
+	for _, stepResult := range scenario.StepResults {
+		duration += stepResult.StepTime
+	}
+	return duration
+}
+
+// Masked returns a copy of the scenario result where the secrets are masked.
+func (scenario ScenarioResult) Masked() ScenarioResult {
+	steps := scenario.StepResults
+	scenario.StepResults = nil
+	for _, step := range steps {
+		scenario.StepResults = append(scenario.StepResults, step.Masked())
+	}
+	return scenario
+}
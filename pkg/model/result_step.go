@@ -1,7 +1,10 @@
 package model
 
 import (
+	"encoding/json"
+	"errors"
 	"github.com/sendgrid/rest"
+	"github.com/thomaspoignant/api-scenario/pkg/secret"
 	"time"
 )
 
@@ -9,6 +12,7 @@ type ResultStep struct {
 	// Common result for every step types
 	StepType StepType      `json:"step_type"`
 	StepTime time.Duration `json:"step_time,omitempty"`
+	Err      error         `json:"error,omitempty"`
 
 	// Specific for type request
 	Request          rest.Request      `json:"request,omitempty"`
@@ -16,10 +20,32 @@ type ResultStep struct {
 	Assertions       []ResultAssertion `json:"assertions,omitempty"`
 	VariablesApplied []ResultVariable  `json:"variables_applied,omitempty"`
 	VariablesCreated []ResultVariable  `json:"variables_created,omitempty"`
+	Attempts         []ResultStep      `json:"attempts,omitempty"`
+}
+
+// MarshalJSON writes the error as its message, encoding/json writes an error interface as an empty object.
+func (step ResultStep) MarshalJSON() ([]byte, error) {
+	type resultStep ResultStep
+	return json.Marshal(struct {
+		resultStep
+		Err string `json:"error,omitempty"`
+	}{resultStep(step), errorMessage(step.Err)})
+}
+
+// errorMessage returns the message of the error, an empty string if there is no error.
+func errorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
 }
 
 // IsSuccess check if the step was a success or not.
 func (step *ResultStep) IsSuccess() bool {
+	if step.Err != nil {
+		return false
+	}
+
 	for _, assert := range step.Assertions {
 		if !assert.Success {
 			return false
@@ -34,3 +60,72 @@ func (step *ResultStep) IsSuccess() bool {
 	}
 	return true
 }
+
+// Masked returns a copy of the step result where the secrets are masked, it is used before saving the result.
+// The credentials are masked in the request, the response, the assertions and the variables.
+func (step ResultStep) Masked() ResultStep {
+	step.Err = maskError(step.Err)
+	step.Request = maskRequest(step.Request)
+	step.Response = step.Response.Masked()
+
+	assertions := step.Assertions
+	step.Assertions = nil
+	for _, assertion := range assertions {
+		step.Assertions = append(step.Assertions, assertion.Masked())
+	}
+	step.VariablesApplied = maskVariables(step.VariablesApplied)
+	step.VariablesCreated = maskVariables(step.VariablesCreated)
+
+	attempts := step.Attempts
+	step.Attempts = nil
+	for _, attempt := range attempts {
+		step.Attempts = append(step.Attempts, attempt.Masked())
+	}
+	return step
+}
+
+func maskVariables(variables []ResultVariable) []ResultVariable {
+	var result []ResultVariable
+	for _, variable := range variables {
+		result = append(result, variable.Masked())
+	}
+	return result
+}
+
+func maskRequest(req rest.Request) rest.Request {
+	registry := secret.GetRegistry()
+	req.BaseURL = registry.Mask(req.BaseURL)
+	if len(req.Body) > 0 {
+		req.Body = []byte(registry.Mask(string(req.Body)))
+	}
+
+	headers := req.Headers
+	req.Headers = nil
+	for key, value := range headers {
+		if req.Headers == nil {
+			req.Headers = make(map[string]string, len(headers))
+		}
+		req.Headers[key] = registry.MaskHeader(key, value)
+	}
+
+	params := req.QueryParams
+	req.QueryParams = nil
+	for key, value := range params {
+		if req.QueryParams == nil {
+			req.QueryParams = make(map[string]string, len(params))
+		}
+		req.QueryParams[key] = registry.Mask(value)
+	}
+	return req
+}
+
+// maskError returns an error with the secrets masked, the error is kept if it does not contain any secret.
+func maskError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if masked := secret.GetRegistry().Mask(err.Error()); masked != err.Error() {
+		return errors.New(masked)
+	}
+	return err
+}
@@ -1,9 +1,15 @@
 package model_test
 
 import (
+	"encoding/json"
 	"fmt"
+	"github.com/ghodss/yaml"
+	"github.com/sendgrid/rest"
 	"github.com/thomaspoignant/api-scenario/pkg/model"
+	"github.com/thomaspoignant/api-scenario/pkg/secret"
 	"github.com/thomaspoignant/api-scenario/test"
+	"net/http"
+	"strings"
 	"testing"
 )
 
@@ -97,3 +103,85 @@ func TestResultStepIsSuccessRequestVariableAppliedFailed(t *testing.T) {
 	got := resStep.IsSuccess()
 	test.Equals(t, "VariablesApplied error should be on error", false, got)
 }
+
+func TestResultStepIsSuccessRequestError(t *testing.T) {
+	resStep := model.ResultStep{
+		StepType: model.RequestStep,
+		Err:      fmt.Errorf("the request timed out after 2s"),
+	}
+	got := resStep.IsSuccess()
+	test.Equals(t, "Step in error should be on error", false, got)
+}
+
+func TestResultStepMasked(t *testing.T) {
+	registry := secret.GetRegistry()
+	defer registry.Reset()
+	registry.AddValue("s3cr3t")
+	registry.AddName("access_token")
+
+	step := model.ResultStep{
+		StepType: model.RequestStep,
+		Request: rest.Request{
+			BaseURL:     "https://test.com/users/s3cr3t",
+			Headers:     map[string]string{"Authorization": "Bearer xyz", "Accept": "application/json"},
+			QueryParams: map[string]string{"key": "s3cr3t"},
+			Body:        []byte(`{"password":"s3cr3t"}`),
+		},
+		Response: model.Response{
+			Body:    `{"echo":"s3cr3t"}`,
+			Header:  http.Header{"Set-Cookie": {"session=abc"}, "Content-Type": {"application/json"}},
+			Cookies: []*http.Cookie{{Name: "session", Value: "abc", Raw: "session=abc"}},
+		},
+		Assertions: []model.ResultAssertion{{Message: "s3cr3t was found"}},
+		VariablesApplied: []model.ResultVariable{
+			{Key: "headers.Authorization", NewValue: "Bearer xyz", Type: model.Used},
+		},
+		VariablesCreated: []model.ResultVariable{
+			{Key: "access_token", NewValue: "xyz", Type: model.Created},
+			{Key: "id", NewValue: "42", Err: fmt.Errorf("s3cr3t not found"), Type: model.Created},
+		},
+		Attempts: []model.ResultStep{{Response: model.Response{Body: "s3cr3t"}}},
+	}
+	got := step.Masked()
+
+	test.Equals(t, "should mask the url", "https://test.com/users/****", got.Request.BaseURL)
+	test.Equals(t, "should mask the sensitive headers", map[string]string{"Authorization": "****", "Accept": "application/json"}, got.Request.Headers)
+	test.Equals(t, "should mask the query params", map[string]string{"key": "****"}, got.Request.QueryParams)
+	test.Equals(t, "should mask the request body", `{"password":"****"}`, string(got.Request.Body))
+	test.Equals(t, "should mask the response body", `{"echo":"****"}`, got.Response.Body)
+	test.Equals(t, "should mask the response headers", http.Header{"Set-Cookie": {"****"}, "Content-Type": {"application/json"}}, got.Response.Header)
+	test.Equals(t, "should mask the cookies", "****", got.Response.Cookies[0].Value)
+	test.Equals(t, "should mask the assertions", "**** was found", got.Assertions[0].Message)
+	test.Equals(t, "should mask the applied variables", "****", got.VariablesApplied[0].NewValue)
+	test.Equals(t, "should mask the secret variables", "****", got.VariablesCreated[0].NewValue)
+	test.Equals(t, "should keep the other variables", "42", got.VariablesCreated[1].NewValue)
+	test.Equals(t, "should mask the errors", "**** not found", got.VariablesCreated[1].Err.Error())
+	test.Equals(t, "should mask the attempts", "****", got.Attempts[0].Response.Body)
+
+	test.Equals(t, "should not modify the original step", "Bearer xyz", step.Request.Headers["Authorization"])
+	test.Equals(t, "should not modify the original cookies", "abc", step.Response.Cookies[0].Value)
+}
+
+func TestResultStepMarshalErrors(t *testing.T) {
+	resStep := model.ResultStep{
+		StepType: model.RequestStep,
+		Err:      fmt.Errorf("the request timed out after 2s"),
+		Assertions: []model.ResultAssertion{
+			{Success: false, Message: "'world' was not equal to hello", Err: fmt.Errorf("invalid value")},
+		},
+		VariablesApplied: []model.ResultVariable{
+			{Key: "token", Err: fmt.Errorf("the environment variable TOKEN is not set"), Type: model.Used},
+		},
+	}
+
+	got, err := json.Marshal(resStep)
+	test.Ok(t, err)
+	want := `{"step_type":"request","request":{"Method":"","BaseURL":"","Headers":null,"QueryParams":null,"Body":null},` +
+		`"response":{},"assertions":[{"success":false,"message":"'world' was not equal to hello","error":"invalid value"}],` +
+		`"variables_applied":[{"name":"token","error":"the environment variable TOKEN is not set"}],"error":"the request timed out after 2s"}`
+	test.Equals(t, "Should marshal the error messages", want, string(got))
+
+	gotYaml, err := yaml.Marshal(resStep)
+	test.Ok(t, err)
+	test.Assert(t, strings.Contains(string(gotYaml), "error: the request timed out after 2s"), "Should marshal the error in YAML: %s", gotYaml)
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type SuiteResult struct {
+	ScenarioResults []ScenarioResult `json:"scenario_results,omitempty"`
+}
+
+// IsSuccess check if every scenario of the suite was a success.
+func (suite *SuiteResult) IsSuccess() bool {
+	for _, scenarioResult := range suite.ScenarioResults {
+		if !scenarioResult.IsSuccess() {
+			return false
+		}
+	}
+	return true
+}
+
+// Masked returns a copy of the suite result where the secrets are masked.
+func (suite SuiteResult) Masked() SuiteResult {
+	scenarios := suite.ScenarioResults
+	suite.ScenarioResults = nil
+	for _, scenario := range scenarios {
+		suite.ScenarioResults = append(suite.ScenarioResults, scenario.Masked())
+	}
+	return suite
+}
+
+// PrintSummary is logging a table with the status of every scenario of the suite.
+func (suite *SuiteResult) PrintSummary() {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tSCENARIO\tFILE\tSTEPS\tDURATION")
+
+	failed := 0
+	for _, scenarioResult := range suite.ScenarioResults {
+		status := "\u2713"
+		if !scenarioResult.IsSuccess() {
+			status = "X"
+			failed++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%v\n",
+			status,
+			scenarioResult.Name,
+			scenarioResult.File,
+			len(scenarioResult.StepResults),
+			scenarioResult.Duration().Round(time.Millisecond))
+	}
+	_ = w.Flush()
+
+	logrus.Info("========================")
+	logrus.Info(buf.String())
+	summary := fmt.Sprintf("%d scenarios, %d passed, %d failed", len(suite.ScenarioResults), len(suite.ScenarioResults)-failed, failed)
+	if failed > 0 {
+		logrus.Error(summary)
+		return
+	}
+	logrus.Info(summary)
+}
@@ -0,0 +1,48 @@
+package model_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thomaspoignant/api-scenario/pkg/model"
+	"github.com/thomaspoignant/api-scenario/test"
+)
+
+func TestSuiteResultIsSuccess(t *testing.T) {
+	success := model.ScenarioResult{
+		StepResults: []model.ResultStep{{Assertions: []model.ResultAssertion{{Success: true}}}},
+	}
+	failure := model.ScenarioResult{
+		StepResults: []model.ResultStep{{Assertions: []model.ResultAssertion{{Success: false}}}},
+	}
+
+	test.Equals(t, "Empty suite should be a success", true, (&model.SuiteResult{}).IsSuccess())
+	suite := model.SuiteResult{ScenarioResults: []model.ScenarioResult{success, success}}
+	test.Equals(t, "All scenarios succeed", true, suite.IsSuccess())
+	suite = model.SuiteResult{ScenarioResults: []model.ScenarioResult{success, failure}}
+	test.Equals(t, "One scenario failed", false, suite.IsSuccess())
+}
+
+func TestSuiteResultPrintSummary(t *testing.T) {
+	test.SetupLog()
+	suite := model.SuiteResult{
+		ScenarioResults: []model.ScenarioResult{
+			{
+				Name:        "First",
+				File:        "first.yml",
+				StepResults: []model.ResultStep{{StepTime: time.Second}, {StepTime: time.Second}},
+			},
+			{
+				Name:        "Second",
+				File:        "second.json",
+				StepResults: []model.ResultStep{{Assertions: []model.ResultAssertion{{Success: false}}}},
+			},
+		},
+	}
+
+	got := test.CaptureOutput(suite.PrintSummary)
+	test.Assert(t, strings.Contains(got, "✓  First     first.yml    2      2s"), "summary should contain the first scenario: %s", got)
+	test.Assert(t, strings.Contains(got, "X  Second    second.json  1      0s"), "summary should contain the second scenario: %s", got)
+	test.Assert(t, strings.HasSuffix(got, "2 scenarios, 1 passed, 1 failed\n"), "summary should end with totals: %s", got)
+}
@@ -1,9 +1,13 @@
 package model
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
+
 	"github.com/sirupsen/logrus"
 	"github.com/thomaspoignant/api-scenario/pkg/log"
+	"github.com/thomaspoignant/api-scenario/pkg/secret"
 )
 
 type ResultVariableType int
@@ -21,7 +25,34 @@ type ResultVariable struct {
 	Type     ResultVariableType `json:"-"`
 }
 
-func (rv *ResultVariable) Print() {
+// MarshalJSON writes the error as its message, encoding/json writes an error interface as an empty object.
+func (rv ResultVariable) MarshalJSON() ([]byte, error) {
+	type resultVariable ResultVariable
+	return json.Marshal(struct {
+		resultVariable
+		Err string `json:"error,omitempty"`
+	}{resultVariable(rv), errorMessage(rv.Err)})
+}
+
+// Masked returns a copy of the variable where the secrets are masked.
+// The value of a secret variable or of a sensitive header is fully masked.
+func (rv ResultVariable) Masked() ResultVariable {
+	header := strings.TrimPrefix(rv.Key, "headers.")
+	switch {
+	case rv.Type == Created && secret.GetRegistry().IsSecret(rv.Key, rv.NewValue),
+		rv.Type == Used && header != rv.Key && secret.IsSensitiveHeader(header):
+		rv.NewValue = secret.Placeholder
+	default:
+		rv.NewValue = secret.GetRegistry().Mask(rv.NewValue)
+	}
+	rv.Err = maskError(rv.Err)
+	return rv
+}
+
+// Print is logging the variable and if it was successfully applied, the secrets are masked.
+func (rv *ResultVariable) Print(logger *logrus.Logger) {
+	masked := rv.Masked()
+	rv = &masked
 	explanation := ""
 	if rv.Type == Created {
 		explanation += fmt.Sprintf("%s '%s' is set to '%s'", rv.Type, rv.Key, rv.NewValue)
@@ -30,8 +61,8 @@ func (rv *ResultVariable) Print() {
 	}
 
 	if rv.Err == nil {
-		logrus.Infof(log.SuccessColor.Sprint("\u2713\t")+"%s", explanation)
+		logger.Infof(log.SuccessColor.Sprint("\u2713\t")+"%s", explanation)
 		return
 	}
-	logrus.Errorf("X\t%s\n\t- %s", explanation, rv.Err.Error())
+	logger.Errorf("X\t%s\n\t- %s", explanation, rv.Err.Error())
 }
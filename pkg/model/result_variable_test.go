@@ -2,6 +2,7 @@ package model_test
 
 import (
 	"fmt"
+	"github.com/sirupsen/logrus"
 	"github.com/thomaspoignant/api-scenario/pkg/model"
 	"github.com/thomaspoignant/api-scenario/test"
 	"testing"
@@ -29,10 +30,22 @@ func Test_ResultVariable_Print(t *testing.T) {
 			model.ResultVariable{Key: "test", NewValue: "newValue", Err: fmt.Errorf("random error"), Type: model.Used},
 			"X\tSet 'test' to 'newValue'\n\t- random error\n",
 		},
+		{
+			model.ResultVariable{Key: "api_token", NewValue: "newValue", Type: model.Created},
+			"✓\tVariable 'api_token' is set to '****'\n",
+		},
+		{
+			model.ResultVariable{Key: "has_token", NewValue: "true", Type: model.Created},
+			"✓\tVariable 'has_token' is set to 'true'\n",
+		},
+		{
+			model.ResultVariable{Key: "headers.Authorization", NewValue: "Bearer newValue", Type: model.Used},
+			"✓\tSet 'headers.Authorization' to '****'\n",
+		},
 	}
 
 	for _, tc := range testCases {
-		got := test.CaptureOutput(tc.value.Print)
+		got := test.CaptureOutput(func() { tc.value.Print(logrus.StandardLogger()) })
 		test.Equals(t, "Output should be equals", tc.expected, got)
 	}
 }
@@ -5,15 +5,30 @@ import (
 	"fmt"
 	"github.com/ghodss/yaml"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 )
 
 type Scenario struct {
 	Name        string `json:"name"`
 	Version     string `json:"version"`
-	ExportedAt  int    `json:"exported_at"`
+	ExportedAt  int    `json:"exported_at,omitempty"`
 	Steps       []Step `json:"steps"`
 	Description string `json:"description"`
+
+	// Variables are default values for variables, they are used only if the variable is not already defined.
+	Variables map[string]string `json:"variables,omitempty"`
+
+	// RequiredVariables are the variables that should be defined before running the scenario
+	// (from the command line or an environment file).
+	RequiredVariables []string `json:"required_variables,omitempty"`
+
+	// Auth is the authentication used for all the requests of the scenario.
+	Auth *Auth `json:"auth,omitempty"`
+
+	// TLS is the TLS configuration used for all the requests of the scenario.
+	TLS *TLSConfig `json:"tls,omitempty"`
 }
 
 // InitScenarioFromFile creates a scenario from the input file.
@@ -36,5 +51,110 @@ func InitScenarioFromFile(inputFile string) (Scenario, error) {
 	if err != nil {
 		return Scenario{}, fmt.Errorf("Impossible to read file: %s\n%v", inputFile, err)
 	}
+	data.resolvePaths(filepath.Dir(inputFile))
 	return data, nil
 }
+
+// resolvePaths makes the paths of the files used by the steps relative to the directory of the scenario file.
+func (scenario *Scenario) resolvePaths(dir string) {
+	resolveAssertions := func(assertions []Assertion) {
+		for i := range assertions {
+			assertions[i].ValueFile = resolvePath(dir, assertions[i].ValueFile)
+			// the value of matches_schema is an inline schema or the path of a schema file
+			if assertions[i].Comparison == MatchesSchema && !strings.HasPrefix(strings.TrimSpace(assertions[i].Value), "{") {
+				assertions[i].Value = resolvePath(dir, assertions[i].Value)
+			}
+		}
+	}
+
+	for i := range scenario.Steps {
+		step := &scenario.Steps[i]
+		step.BodyFile = resolvePath(dir, step.BodyFile)
+		resolveAssertions(step.Assertions)
+		if step.Retry != nil {
+			resolveAssertions(step.Retry.Until)
+		}
+		if step.Multipart != nil {
+			for j := range step.Multipart.Files {
+				step.Multipart.Files[j].Path = resolvePath(dir, step.Multipart.Files[j].Path)
+			}
+		}
+	}
+
+	if scenario.TLS != nil {
+		scenario.TLS.CACert = resolvePath(dir, scenario.TLS.CACert)
+		scenario.TLS.ClientCert = resolvePath(dir, scenario.TLS.ClientCert)
+		scenario.TLS.ClientKey = resolvePath(dir, scenario.TLS.ClientKey)
+	}
+}
+
+// resolvePath returns the path relative to the directory, absolute paths and paths starting with a variable are kept.
+func resolvePath(dir string, path string) string {
+	if len(path) == 0 || filepath.IsAbs(path) || strings.HasPrefix(path, "{{") {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// scenarioExtensions are the file extensions we are looking for when we search scenarios in a directory.
+var scenarioExtensions = []string{".json", ".yml", ".yaml"}
+
+// ListScenarioFiles resolves a list of files, glob patterns and directories into scenario files.
+// Directories are walked recursively to find every .json, .yml and .yaml files.
+func ListScenarioFiles(inputs []string) ([]string, error) {
+	var result []string
+	alreadyAdded := map[string]bool{}
+	add := func(file string) {
+		if !alreadyAdded[file] {
+			alreadyAdded[file] = true
+			result = append(result, file)
+		}
+	}
+
+	for _, input := range inputs {
+		matches, err := filepath.Glob(input)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %v", input, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("Impossible to locate the file: %s", input)
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, fmt.Errorf("Impossible to locate the file: %s\n Error: %v", match, err)
+			}
+
+			if !info.IsDir() {
+				add(match)
+				continue
+			}
+
+			err = filepath.Walk(match, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				if !info.IsDir() && isScenarioFile(path) {
+					add(path)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("Impossible to read the directory: %s\n Error: %v", match, err)
+			}
+		}
+	}
+	return result, nil
+}
+
+// isScenarioFile checks if the file has an extension of a scenario.
+func isScenarioFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, scenarioExt := range scenarioExtensions {
+		if ext == scenarioExt {
+			return true
+		}
+	}
+	return false
+}
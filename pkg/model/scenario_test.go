@@ -2,6 +2,7 @@ package model_test
 
 import (
 	"github.com/thomaspoignant/api-scenario/pkg/model"
+	"path/filepath"
 	"reflect"
 	"testing"
 )
@@ -70,3 +71,91 @@ func TestInitScenarioFromFile(t *testing.T) {
 		})
 	}
 }
+
+func TestListScenarioFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		inputs  []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			"Single file",
+			[]string{"../../testdata/scenario_valid_json.json"},
+			[]string{"../../testdata/scenario_valid_json.json"},
+			false,
+		},
+		{
+			"Glob pattern",
+			[]string{"../../testdata/*.yml"},
+			[]string{"../../testdata/scenario_invalid_yaml.yml", "../../testdata/scenario_valid_yaml.yml"},
+			false,
+		},
+		{
+			"Directory",
+			[]string{"../../testdata/directory"},
+			[]string{
+				"../../testdata/directory/first.yml",
+				"../../testdata/directory/sub/second.json",
+			},
+			false,
+		},
+		{
+			"Duplicates are removed",
+			[]string{"../../testdata/scenario_valid_yaml.yml", "../../testdata/*_yaml.yml"},
+			[]string{"../../testdata/scenario_valid_yaml.yml", "../../testdata/scenario_invalid_yaml.yml"},
+			false,
+		},
+		{"File does not exist", []string{"../../testdata/does_not_exist.json"}, nil, true},
+		{"Invalid pattern", []string{"../../testdata/[.json"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := model.ListScenarioFiles(tt.inputs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ListScenarioFiles() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ListScenarioFiles() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInitScenarioFromFileResolvePaths(t *testing.T) {
+	got, err := model.InitScenarioFromFile("../../testdata/upload/scenario.yml")
+	if err != nil {
+		t.Fatalf("InitScenarioFromFile() error = %v", err)
+	}
+
+	files := got.Steps[0].Multipart.Files
+	want := []string{filepath.Join("../../testdata/upload", "avatar.png"), "/tmp/avatar.png", "{{avatar_dir}}/avatar.png"}
+	for i, file := range files {
+		if file.Path != want[i] {
+			t.Errorf("InitScenarioFromFile() path = %v, want %v", file.Path, want[i])
+		}
+	}
+
+	if want := filepath.Join("../../testdata/upload", "user.json"); got.Steps[1].BodyFile != want {
+		t.Errorf("InitScenarioFromFile() body_file = %v, want %v", got.Steps[1].BodyFile, want)
+	}
+	if want := filepath.Join("../../testdata/upload", "welcome.txt"); got.Steps[1].Assertions[0].ValueFile != want {
+		t.Errorf("InitScenarioFromFile() value_file = %v, want %v", got.Steps[1].Assertions[0].ValueFile, want)
+	}
+}
+
+func TestInitScenarioFromFileResolveSchemaPaths(t *testing.T) {
+	got, err := model.InitScenarioFromFile("../../testdata/schema/scenario.yml")
+	if err != nil {
+		t.Fatalf("InitScenarioFromFile() error = %v", err)
+	}
+
+	assertions := got.Steps[0].Assertions
+	if want := filepath.Join("../../testdata/schema", "user.json"); assertions[0].Value != want {
+		t.Errorf("InitScenarioFromFile() schema file = %v, want %v", assertions[0].Value, want)
+	}
+	if want := `{"type": "object"}`; assertions[1].Value != want {
+		t.Errorf("InitScenarioFromFile() inline schema = %v, want %v", assertions[1].Value, want)
+	}
+}
@@ -10,4 +10,7 @@ const (
 	ResponseHeader               //response_header
 	ResponseText                 //response_text
 	ResponseXml                  //response_xml
+	ResponseHtml                 //response_html
+	ResponseCookie               //response_cookie
+	ResponseTLS                  //response_tls
 )
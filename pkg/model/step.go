@@ -2,13 +2,43 @@ package model
 
 type Step struct {
 	StepType  StepType   `json:"step_type"`
-	URL       string     `json:"Url,omitempty"`
+	URL       string     `json:"url,omitempty"`
 	Variables []Variable `json:"variables,omitempty"`
 
 	Headers    map[string][]string `json:"headers,omitempty"`
 	Assertions []Assertion         `json:"assertions,omitempty"`
 	Method     string              `json:"method,omitempty"`
 	Duration   int                 `json:"duration,omitempty"`
+	Timeout    int                 `json:"timeout,omitempty"`
+	Retry      *Retry              `json:"retry,omitempty"`
 	Body       string              `json:"body,omitempty"`
+	BodyFile   string              `json:"body_file,omitempty"`  // file with the body, relative to the scenario file
+	Form       map[string]string   `json:"form,omitempty"`       // fields of an application/x-www-form-urlencoded body
+	Multipart  *Multipart          `json:"multipart,omitempty"`  // fields and files of a multipart/form-data body
+	Namespaces map[string]string   `json:"namespaces,omitempty"` // XML namespaces (prefix: URI) usable in the XPath expressions
+	Cookies    CookieMode          `json:"cookies,omitempty"`    // clear the cookie jar before the request or disable it for the request
+	Auth       *Auth               `json:"auth,omitempty"`       // replaces the authentication of the scenario for the request
 	Skipped    bool                `json:"skipped,omitempty"`
 }
+
+// Retry defines how a request is sent again until it succeeds.
+type Retry struct {
+	MaxAttempts int         `json:"max_attempts"`
+	Interval    int         `json:"interval,omitempty"` // in seconds
+	Backoff     float64     `json:"backoff,omitempty"`  // multiplier applied to the interval after each attempt
+	Until       []Assertion `json:"until,omitempty"`
+}
+
+// Multipart is a multipart/form-data body.
+type Multipart struct {
+	Fields map[string]string `json:"fields,omitempty"`
+	Files  []MultipartFile   `json:"files,omitempty"`
+}
+
+// MultipartFile is a file from the disk sent in a multipart/form-data body.
+type MultipartFile struct {
+	Field       string `json:"field"`
+	Path        string `json:"path"`                   // relative to the scenario file
+	Filename    string `json:"filename,omitempty"`     // name of the file in the request (default: name of the file on disk)
+	ContentType string `json:"content_type,omitempty"` // default: guessed from the file extension
+}
@@ -0,0 +1,108 @@
+package model
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"time"
+)
+
+// TLSConfig is the TLS configuration used to call the API.
+type TLSConfig struct {
+	// CACert is a PEM file with the certificates of the authorities trusted in addition to the system ones.
+	CACert string `json:"ca_cert,omitempty"`
+	// ClientCert and ClientKey are the PEM files of the client certificate used for mutual TLS.
+	ClientCert string `json:"client_cert,omitempty"`
+	ClientKey  string `json:"client_key,omitempty"`
+	// ServerName overrides the name used to verify the certificate of the server.
+	ServerName string `json:"server_name,omitempty"`
+	// Insecure disables the verification of the certificate of the server, it can not be enabled again by an override.
+	Insecure bool `json:"insecure,omitempty"`
+}
+
+// Override returns the configuration where the fields set in override replace the current ones.
+// Insecure is one-way: the verification of the server is disabled if one of the configurations is insecure,
+// an override with insecure unset (false) can not enable it again.
+func (config *TLSConfig) Override(override *TLSConfig) *TLSConfig {
+	if config == nil {
+		return override
+	}
+	result := *config
+	if override == nil {
+		return &result
+	}
+	if len(override.CACert) > 0 {
+		result.CACert = override.CACert
+	}
+	if len(override.ClientCert) > 0 {
+		result.ClientCert = override.ClientCert
+		result.ClientKey = override.ClientKey
+	}
+	if len(override.ServerName) > 0 {
+		result.ServerName = override.ServerName
+	}
+	result.Insecure = result.Insecure || override.Insecure
+	return &result
+}
+
+// TLSInfo describes the TLS connection used to receive a response.
+type TLSInfo struct {
+	Version         string           `json:"version,omitempty"`      // e.g. TLS 1.3
+	CipherSuite     string           `json:"cipher_suite,omitempty"` // e.g. TLS_AES_128_GCM_SHA256
+	ServerName      string           `json:"server_name,omitempty"`
+	PeerCertificate *CertificateInfo `json:"peer_certificate,omitempty"`
+}
+
+// CertificateInfo describes the certificate sent by the server.
+type CertificateInfo struct {
+	Subject   string    `json:"subject,omitempty"`
+	Issuer    string    `json:"issuer,omitempty"`
+	SANs      []string  `json:"sans,omitempty"`
+	NotBefore time.Time `json:"not_before"`
+	NotAfter  time.Time `json:"not_after"`
+}
+
+// tlsVersions are the names of the TLS protocol versions.
+var tlsVersions = map[uint16]string{
+	tls.VersionTLS10: "TLS 1.0",
+	tls.VersionTLS11: "TLS 1.1",
+	tls.VersionTLS12: "TLS 1.2",
+	tls.VersionTLS13: "TLS 1.3",
+}
+
+// NewTLSInfo creates the TLS details from the state of a connection, it returns nil without TLS.
+func NewTLSInfo(state *tls.ConnectionState) *TLSInfo {
+	if state == nil {
+		return nil
+	}
+	info := &TLSInfo{
+		Version:     tlsVersions[state.Version],
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+		ServerName:  state.ServerName,
+	}
+	if len(info.Version) == 0 {
+		info.Version = "unknown"
+	}
+	if len(state.PeerCertificates) > 0 {
+		info.PeerCertificate = newCertificateInfo(state.PeerCertificates[0])
+	}
+	return info
+}
+
+// newCertificateInfo extracts the details of a certificate, the SANs are the DNS names, the emails, the IPs and the URIs.
+func newCertificateInfo(cert *x509.Certificate) *CertificateInfo {
+	info := &CertificateInfo{
+		Subject:   cert.Subject.String(),
+		Issuer:    cert.Issuer.String(),
+		NotBefore: cert.NotBefore,
+		NotAfter:  cert.NotAfter,
+	}
+	info.SANs = append(info.SANs, cert.DNSNames...)
+	info.SANs = append(info.SANs, cert.EmailAddresses...)
+	for _, ip := range cert.IPAddresses {
+		info.SANs = append(info.SANs, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		info.SANs = append(info.SANs, uri.String())
+	}
+	return info
+}
@@ -0,0 +1,54 @@
+package model_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/thomaspoignant/api-scenario/pkg/model"
+	"github.com/thomaspoignant/api-scenario/test"
+)
+
+func TestNewTLSInfo(t *testing.T) {
+	notAfter := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	state := &tls.ConnectionState{
+		Version:     tls.VersionTLS12,
+		CipherSuite: tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		ServerName:  "api.test.com",
+		PeerCertificates: []*x509.Certificate{{
+			Subject:     pkix.Name{CommonName: "api.test.com", Organization: []string{"Test"}},
+			Issuer:      pkix.Name{CommonName: "Test CA"},
+			DNSNames:    []string{"api.test.com", "*.test.com"},
+			IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+			NotAfter:    notAfter,
+		}},
+	}
+
+	want := &model.TLSInfo{
+		Version:     "TLS 1.2",
+		CipherSuite: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+		ServerName:  "api.test.com",
+		PeerCertificate: &model.CertificateInfo{
+			Subject:  "CN=api.test.com,O=Test",
+			Issuer:   "CN=Test CA",
+			SANs:     []string{"api.test.com", "*.test.com", "127.0.0.1"},
+			NotAfter: notAfter,
+		},
+	}
+	test.Equals(t, "Should describe the TLS connection", want, model.NewTLSInfo(state))
+	test.Assert(t, model.NewTLSInfo(nil) == nil, "Should be nil without TLS")
+}
+
+func TestTLSConfigOverride(t *testing.T) {
+	var empty *model.TLSConfig
+	config := &model.TLSConfig{CACert: "ca.pem", ClientCert: "cli.pem", ClientKey: "cli-key.pem", Insecure: true}
+	test.Equals(t, "Should use the override without configuration", config, empty.Override(config))
+
+	got := config.Override(&model.TLSConfig{ClientCert: "scenario.pem", ClientKey: "scenario-key.pem", ServerName: "api"})
+	want := &model.TLSConfig{CACert: "ca.pem", ClientCert: "scenario.pem", ClientKey: "scenario-key.pem", ServerName: "api", Insecure: true}
+	test.Equals(t, "Should override the fields set", want, got)
+	test.Equals(t, "Should not change the configuration", "cli.pem", config.ClientCert)
+}
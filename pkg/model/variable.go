@@ -1,7 +1,9 @@
 package model
 
 type Variable struct {
-	Source   Source `json:"Source"`
+	Source   Source `json:"source"`
 	Property string `json:"property"`
 	Name     string `json:"name"`
+	Regex    string `json:"regex,omitempty"` // extract only the part of the value matching the regex
+	Group    string `json:"group,omitempty"` // name or number of the group to extract, the first group by default
 }
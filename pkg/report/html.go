@@ -0,0 +1,231 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/thomaspoignant/api-scenario/pkg/model"
+	"github.com/thomaspoignant/api-scenario/pkg/util"
+)
+
+// htmlReport is the data used to render the HTML report.
+type htmlReport struct {
+	GeneratedAt string
+	Total       int
+	Passed      int
+	Failed      int
+	Scenarios   []htmlScenario
+}
+
+type htmlScenario struct {
+	Name        string
+	Version     string
+	Description string
+	Success     bool
+	Steps       []htmlStep
+}
+
+type htmlStep struct {
+	Index            int
+	Title            string
+	Success          bool
+	IsRequest        bool
+	Duration         string
+	Err              error
+	Method           string
+	URL              string
+	RequestHeaders   []htmlHeader
+	RequestBody      string
+	StatusCode       int
+	ResponseHeaders  []htmlHeader
+	ResponseBody     string
+	Assertions       []model.ResultAssertion
+	VariablesApplied []model.ResultVariable
+	VariablesCreated []model.ResultVariable
+}
+
+type htmlHeader struct {
+	Name  string
+	Value string
+}
+
+// HTML is converting the results of the scenarios into a self-contained HTML report.
+func HTML(results []model.ScenarioResult) ([]byte, error) {
+	data := htmlReport{
+		GeneratedAt: time.Now().Format(time.RFC1123),
+		Total:       len(results),
+	}
+
+	for _, result := range results {
+		scenario := htmlScenario{
+			Name:        result.Name,
+			Version:     result.Version,
+			Description: result.Description,
+			Success:     result.IsSuccess(),
+		}
+		if scenario.Success {
+			data.Passed++
+		} else {
+			data.Failed++
+		}
+
+		for index, step := range result.StepResults {
+			scenario.Steps = append(scenario.Steps, convertStepToHtml(index, step))
+		}
+		data.Scenarios = append(data.Scenarios, scenario)
+	}
+
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// convertStepToHtml is extracting from the step the information to display.
+func convertStepToHtml(index int, step model.ResultStep) htmlStep {
+	res := htmlStep{
+		Index:            index + 1,
+		Title:            stepName(index, step),
+		Success:          step.IsSuccess(),
+		IsRequest:        step.StepType == model.RequestStep,
+		Duration:         step.StepTime.Round(time.Millisecond).String(),
+		Err:              step.Err,
+		Assertions:       step.Assertions,
+		VariablesApplied: step.VariablesApplied,
+		VariablesCreated: step.VariablesCreated,
+	}
+
+	if !res.IsRequest {
+		return res
+	}
+	if len(step.Attempts) > 1 {
+		res.Title += fmt.Sprintf(" - %d attempts", len(step.Attempts))
+	}
+
+	res.Method = string(step.Request.Method)
+	res.URL = step.Request.BaseURL
+	if len(step.Request.QueryParams) > 0 {
+		var params []string
+		for key, value := range step.Request.QueryParams {
+			params = append(params, fmt.Sprintf("%s=%s", key, value))
+		}
+		sort.Strings(params)
+		res.URL += "?" + strings.Join(params, "&")
+	}
+	for key, value := range step.Request.Headers {
+		res.RequestHeaders = append(res.RequestHeaders, htmlHeader{Name: key, Value: value})
+	}
+	sort.Slice(res.RequestHeaders, func(i, j int) bool { return res.RequestHeaders[i].Name < res.RequestHeaders[j].Name })
+	res.RequestBody = prettyPrintBody(string(step.Request.Body))
+
+	res.StatusCode = step.Response.StatusCode
+	for key, values := range step.Response.Header {
+		res.ResponseHeaders = append(res.ResponseHeaders, htmlHeader{Name: key, Value: strings.Join(values, ", ")})
+	}
+	sort.Slice(res.ResponseHeaders, func(i, j int) bool { return res.ResponseHeaders[i].Name < res.ResponseHeaders[j].Name })
+	res.ResponseBody = prettyPrintBody(step.Response.Body)
+	return res
+}
+
+// prettyPrintBody is indenting the body if it is a JSON document.
+func prettyPrintBody(body string) string {
+	if len(strings.TrimSpace(body)) == 0 || !util.IsJson(body) {
+		return body
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(body), "", "  "); err != nil {
+		return body
+	}
+	return buf.String()
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>api-scenario report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292e; }
+h1 { margin-bottom: 0; }
+.generated { color: #6a737d; margin-top: 0.2em; }
+.summary { display: flex; gap: 1em; margin: 1.5em 0; }
+.summary div { padding: 0.8em 1.5em; border-radius: 6px; background: #f6f8fa; font-size: 1.2em; }
+.success { color: #22863a; }
+.failure { color: #cb2431; }
+details { border: 1px solid #e1e4e8; border-radius: 6px; margin: 0.5em 0; padding: 0.5em 1em; }
+details.scenario > summary { font-size: 1.2em; font-weight: bold; }
+summary { cursor: pointer; }
+pre { background: #f6f8fa; padding: 0.8em; overflow-x: auto; border-radius: 4px; }
+table { border-collapse: collapse; margin: 0.5em 0; }
+td { padding: 0.2em 0.8em; border-bottom: 1px solid #eaecef; vertical-align: top; }
+td.name { font-weight: bold; }
+.badge { display: inline-block; min-width: 1.5em; text-align: center; }
+</style>
+</head>
+<body>
+<h1>api-scenario report</h1>
+<p class="generated">Generated on {{.GeneratedAt}}</p>
+<div class="summary">
+  <div>Scenarios: <b>{{.Total}}</b></div>
+  <div class="success">Passed: <b>{{.Passed}}</b></div>
+  <div class="failure">Failed: <b>{{.Failed}}</b></div>
+</div>
+{{range .Scenarios}}
+<details class="scenario"{{if not .Success}} open{{end}}>
+  <summary><span class="badge {{if .Success}}success">&#10003;{{else}}failure">&#10007;{{end}}</span> {{.Name}}{{if .Version}} ({{.Version}}){{end}}</summary>
+  {{if .Description}}<p>{{.Description}}</p>{{end}}
+  {{range .Steps}}
+  <details class="step"{{if not .Success}} open{{end}}>
+    <summary><span class="badge {{if .Success}}success">&#10003;{{else}}failure">&#10007;{{end}}</span> {{.Title}} <small>({{.Duration}})</small></summary>
+    {{if .Err}}<p class="failure">{{.Err}}</p>{{end}}
+    {{if .IsRequest}}
+    <details>
+      <summary>Request</summary>
+      <p><b>{{.Method}}</b> {{.URL}}</p>
+      {{if .RequestHeaders}}<table>{{range .RequestHeaders}}<tr><td class="name">{{.Name}}</td><td>{{.Value}}</td></tr>{{end}}</table>{{end}}
+      {{if .RequestBody}}<pre>{{.RequestBody}}</pre>{{end}}
+    </details>
+    <details>
+      <summary>Response</summary>
+      <p>Status: <b>{{.StatusCode}}</b></p>
+      {{if .ResponseHeaders}}<table>{{range .ResponseHeaders}}<tr><td class="name">{{.Name}}</td><td>{{.Value}}</td></tr>{{end}}</table>{{end}}
+      {{if .ResponseBody}}<pre>{{.ResponseBody}}</pre>{{end}}
+    </details>
+    {{end}}
+    {{if .Assertions}}
+    <details open>
+      <summary>Assertions</summary>
+      <table>{{range .Assertions}}
+        <tr><td class="{{if .Success}}success">&#10003;{{else}}failure">&#10007;{{end}}</td><td>{{.Source}}{{if .Property}}.{{.Property}}{{end}}</td><td>{{.Message}}</td></tr>{{end}}
+      </table>
+    </details>
+    {{end}}
+    {{if .VariablesApplied}}
+    <details>
+      <summary>Variables applied</summary>
+      <table>{{range .VariablesApplied}}
+        <tr><td class="{{if .Err}}failure">&#10007;{{else}}success">&#10003;{{end}}</td><td class="name">{{.Key}}</td><td>{{.NewValue}}</td><td>{{if .Err}}{{.Err}}{{end}}</td></tr>{{end}}
+      </table>
+    </details>
+    {{end}}
+    {{if .VariablesCreated}}
+    <details>
+      <summary>Variables created</summary>
+      <table>{{range .VariablesCreated}}
+        <tr><td class="{{if .Err}}failure">&#10007;{{else}}success">&#10003;{{end}}</td><td class="name">{{.Key}}</td><td>{{.NewValue}}</td><td>{{if .Err}}{{.Err}}{{end}}</td></tr>{{end}}
+      </table>
+    </details>
+    {{end}}
+  </details>
+  {{end}}
+</details>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,69 @@
+package report_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sendgrid/rest"
+	"github.com/thomaspoignant/api-scenario/pkg/model"
+	"github.com/thomaspoignant/api-scenario/pkg/report"
+	"github.com/thomaspoignant/api-scenario/test"
+)
+
+func TestHTML(t *testing.T) {
+	results := []model.ScenarioResult{
+		{
+			Name:    "Test Scenario",
+			Version: "1.0",
+			StepResults: []model.ResultStep{
+				{
+					StepType: model.RequestStep,
+					StepTime: 1500 * time.Millisecond,
+					Request: rest.Request{
+						Method:      rest.Post,
+						BaseURL:     "http://test.com/users",
+						QueryParams: map[string]string{"page": "1"},
+						Headers:     map[string]string{"Content-Type": "application/json"},
+						Body:        []byte(`{"name":"<john>"}`),
+					},
+					Response: model.Response{
+						StatusCode: 201,
+						Header:     http.Header{"X-Request-Id": {"1234"}},
+						Body:       `{"id":1}`,
+					},
+					Assertions: []model.ResultAssertion{
+						{Success: false, Source: model.ResponseJson, Property: "id", Message: "'1' was not equal to 2"},
+					},
+					VariablesCreated: []model.ResultVariable{
+						{Key: "user_id", Err: errors.New("key not found"), Type: model.Created},
+					},
+				},
+			},
+		},
+		{Name: "Success Scenario"},
+	}
+
+	got, err := report.HTML(results)
+	test.Ok(t, err)
+	html := string(got)
+
+	expected := []string{
+		"Passed: <b>1</b>",
+		"Failed: <b>1</b>",
+		"<b>POST</b> http://test.com/users?page=1",
+		"<td class=\"name\">Content-Type</td><td>application/json</td>",
+		"&#34;name&#34;: &#34;&lt;john&gt;&#34;",
+		"<p>Status: <b>201</b></p>",
+		"<td class=\"name\">X-Request-Id</td><td>1234</td>",
+		"response_json.id",
+		"&#39;1&#39; was not equal to 2",
+		"key not found",
+	}
+	for _, item := range expected {
+		test.Assert(t, strings.Contains(html, item), "HTML report should contain %q", item)
+	}
+	test.Assert(t, !strings.Contains(html, "<link") && !strings.Contains(html, "<script"), "HTML report should not use external assets")
+}
@@ -0,0 +1,158 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/thomaspoignant/api-scenario/pkg/model"
+)
+
+// junitTestSuites is the root element of a JUnit XML report.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite represents a scenario in the JUnit XML report.
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	Version   string          `xml:"version,attr,omitempty"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase represents a step in the JUnit XML report.
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+// junitFailure represents the failed assertions and the errored variables of a step in the JUnit XML report,
+// a testcase has at most one failure so the JUnit consumers display all the problems of the step.
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Content string `xml:",chardata"`
+}
+
+// JUnit is converting the results of the scenarios into a JUnit XML report.
+// Each scenario is a <testsuite> and each step is a <testcase>.
+func JUnit(results []model.ScenarioResult) ([]byte, error) {
+	report := junitTestSuites{}
+	var totalTime time.Duration
+
+	for _, result := range results {
+		suite := junitTestSuite{
+			Name:    result.Name,
+			Version: result.Version,
+		}
+
+		var suiteTime time.Duration
+		for index, step := range result.StepResults {
+			testCase := junitTestCase{
+				Name:      stepName(index, step),
+				ClassName: result.Name,
+				Time:      formatSeconds(step.StepTime),
+				Failure:   stepFailure(step),
+			}
+			suite.TestCases = append(suite.TestCases, testCase)
+			suite.Tests++
+			if testCase.Failure != nil {
+				suite.Failures++
+			}
+			suiteTime += step.StepTime
+		}
+		suite.Time = formatSeconds(suiteTime)
+
+		report.Suites = append(report.Suites, suite)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		totalTime += suiteTime
+	}
+	report.Time = formatSeconds(totalTime)
+
+	output, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), output...), nil
+}
+
+// stepName is creating a readable name for a step.
+func stepName(index int, step model.ResultStep) string {
+	if step.StepType == model.RequestStep {
+		return fmt.Sprintf("#%d %s %s", index+1, step.Request.Method, step.Request.BaseURL)
+	}
+	return fmt.Sprintf("#%d %s", index+1, step.StepType)
+}
+
+// stepFailure is aggregating the error, every failed assertion and errored variable of a step in one failure,
+// it returns nil if the step is successful.
+// The type is the one of the first problem, the content has one line by problem with its details.
+func stepFailure(step model.ResultStep) *junitFailure {
+	var failures []junitFailure
+	if step.Err != nil {
+		failures = append(failures, junitFailure{Message: step.Err.Error(), Type: "error"})
+	}
+
+	for _, assertion := range step.Assertions {
+		if assertion.Success {
+			continue
+		}
+		failure := junitFailure{Message: assertion.Message, Type: "assertion"}
+		if assertion.Err != nil {
+			failure.Content = assertion.Err.Error()
+		}
+		failures = append(failures, failure)
+	}
+
+	variables := make([]model.ResultVariable, 0, len(step.VariablesApplied)+len(step.VariablesCreated))
+	variables = append(variables, step.VariablesApplied...)
+	variables = append(variables, step.VariablesCreated...)
+	for _, variable := range variables {
+		if variable.Err == nil {
+			continue
+		}
+		failures = append(failures, junitFailure{
+			Message: fmt.Sprintf("variable '%s': %s", variable.Key, variable.Err.Error()),
+			Type:    "variable",
+			Content: variable.Err.Error(),
+		})
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+	if len(failures) == 1 {
+		return &failures[0]
+	}
+	messages := make([]string, 0, len(failures))
+	lines := make([]string, 0, len(failures))
+	for _, failure := range failures {
+		messages = append(messages, failure.Message)
+		line := fmt.Sprintf("[%s] %s", failure.Type, failure.Message)
+		if len(failure.Content) > 0 {
+			line += ": " + failure.Content
+		}
+		lines = append(lines, line)
+	}
+	return &junitFailure{
+		Message: fmt.Sprintf("%d failures: %s", len(failures), strings.Join(messages, "; ")),
+		Type:    failures[0].Type,
+		Content: strings.Join(lines, "\n"),
+	}
+}
+
+// formatSeconds is formatting a duration in seconds as expected by JUnit.
+func formatSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
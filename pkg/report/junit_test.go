@@ -0,0 +1,69 @@
+package report_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sendgrid/rest"
+	"github.com/thomaspoignant/api-scenario/pkg/model"
+	"github.com/thomaspoignant/api-scenario/pkg/report"
+	"github.com/thomaspoignant/api-scenario/test"
+)
+
+func TestJUnit(t *testing.T) {
+	results := []model.ScenarioResult{
+		{
+			Name:    "Test Scenario",
+			Version: "1.0",
+			StepResults: []model.ResultStep{
+				{
+					StepType: model.Pause,
+					StepTime: 1 * time.Second,
+				},
+				{
+					StepType: model.RequestStep,
+					StepTime: 500 * time.Millisecond,
+					Request:  rest.Request{Method: rest.Get, BaseURL: "http://test.com/timeout"},
+					Err:      errors.New("the request timed out after 2s"),
+				},
+				{
+					StepType: model.RequestStep,
+					StepTime: 1500 * time.Millisecond,
+					Request:  rest.Request{Method: rest.Get, BaseURL: "http://test.com/users"},
+					Assertions: []model.ResultAssertion{
+						{Success: true, Message: "'200' was a number equal to 200"},
+						{Success: false, Message: "'world' was not equal to hello"},
+					},
+					VariablesCreated: []model.ResultVariable{
+						{Key: "user_id", Err: errors.New("key not found"), Type: model.Created},
+					},
+				},
+			},
+		},
+	}
+
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="3" failures="2" time="3.000">
+  <testsuite name="Test Scenario" tests="3" failures="2" time="3.000" version="1.0">
+    <testcase name="#1 pause" classname="Test Scenario" time="1.000"></testcase>
+    <testcase name="#2 GET http://test.com/timeout" classname="Test Scenario" time="0.500">
+      <failure message="the request timed out after 2s" type="error"></failure>
+    </testcase>
+    <testcase name="#3 GET http://test.com/users" classname="Test Scenario" time="1.500">
+      <failure message="2 failures: &#39;world&#39; was not equal to hello; variable &#39;user_id&#39;: key not found" type="assertion">[assertion] &#39;world&#39; was not equal to hello&#xA;[variable] variable &#39;user_id&#39;: key not found: key not found</failure>
+    </testcase>
+  </testsuite>
+</testsuites>`
+
+	got, err := report.JUnit(results)
+	test.Ok(t, err)
+	test.Equals(t, "JUnit report should be the same", want, string(got))
+}
+
+func TestJUnitEmpty(t *testing.T) {
+	got, err := report.JUnit([]model.ScenarioResult{})
+	test.Ok(t, err)
+	test.Assert(t, strings.Contains(string(got), `<testsuites tests="0" failures="0" time="0.000"></testsuites>`), "empty report should have no suite: %s", got)
+}
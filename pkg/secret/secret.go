@@ -0,0 +1,176 @@
+package secret
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Placeholder is the string displayed instead of a secret.
+const Placeholder = "****"
+
+// minDefaultPatternValueLength is the minimum length of a value registered because the name of its variable only
+// matches the default pattern, the shorter values (true, 1...) would mask unrelated parts of the outputs.
+const minDefaultPatternValueLength = 6
+
+// defaultNamePattern matches the names of the variables considered as secret without being declared.
+var defaultNamePattern = regexp.MustCompile(`(?i)(password|passwd|secret|token|api_?key|credential|private_key)`)
+
+// sensitiveHeaders are the headers always masked, their values are also registered as secrets.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":        true,
+	"Proxy-Authorization":  true,
+	"Cookie":               true,
+	"Set-Cookie":           true,
+	"X-Api-Key":            true,
+	"Api-Key":              true,
+	"X-Auth-Token":         true,
+	"X-Amz-Security-Token": true,
+}
+
+// Registry keeps the secrets of the run to mask them in the logs and in the reports.
+// The secrets are still sent on the wire, only what is displayed or saved is masked.
+type Registry struct {
+	mu           sync.RWMutex
+	names        map[string]bool
+	namePatterns []*regexp.Regexp
+	values       map[string]bool
+}
+
+var registry = NewRegistry()
+
+// GetRegistry returns the registry shared by all the scenarios.
+func GetRegistry() *Registry {
+	return registry
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		names:        map[string]bool{},
+		namePatterns: []*regexp.Regexp{},
+		values:       map[string]bool{},
+	}
+}
+
+// Reset removes all the secrets of the registry.
+func (r *Registry) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.names = map[string]bool{}
+	r.namePatterns = []*regexp.Regexp{}
+	r.values = map[string]bool{}
+}
+
+// AddName marks a variable as secret, its value will be registered when the variable is set.
+func (r *Registry) AddName(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.names[name] = true
+}
+
+// AddNamePattern marks as secret all the variables with a name matching the regex.
+func (r *Registry) AddNamePattern(pattern string) error {
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid secret pattern %q: %v", pattern, err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.namePatterns = append(r.namePatterns, regex)
+	return nil
+}
+
+// IsSecretName checks if the variable is secret, by its name or by a pattern.
+func (r *Registry) IsSecretName(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.names[name] || defaultNamePattern.MatchString(name) {
+		return true
+	}
+	for _, pattern := range r.namePatterns {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSecret checks if the value of the variable should be masked: the variable is declared as secret or matches
+// a pattern of --secret-pattern, or it matches the default pattern and its value has at least
+// minDefaultPatternValueLength characters.
+func (r *Registry) IsSecret(name string, value string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.names[name] {
+		return true
+	}
+	for _, pattern := range r.namePatterns {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+	return len(value) >= minDefaultPatternValueLength && defaultNamePattern.MatchString(name)
+}
+
+// AddVariable registers the value of a variable if it is secret.
+func (r *Registry) AddVariable(name string, value string) {
+	if r.IsSecret(name, value) {
+		r.AddValue(value)
+	}
+}
+
+// AddValue registers a secret value, every occurrence of the value will be masked.
+func (r *Registry) AddValue(value string) {
+	if len(strings.TrimSpace(value)) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values[value] = true
+}
+
+// AddHeader registers the value of a sensitive header.
+// The credentials of an authorization header are registered without their scheme (e.g. Bearer).
+func (r *Registry) AddHeader(name string, value string) {
+	if !IsSensitiveHeader(name) {
+		return
+	}
+	r.AddValue(value)
+	if scheme := strings.Index(value, " "); scheme > 0 {
+		r.AddValue(strings.TrimSpace(value[scheme+1:]))
+	}
+}
+
+// Mask replaces every secret value in the string by the placeholder.
+// The longest values are replaced first, so a secret containing another one is fully masked.
+func (r *Registry) Mask(s string) string {
+	r.mu.RLock()
+	values := make([]string, 0, len(r.values))
+	for value := range r.values {
+		values = append(values, value)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	for _, value := range values {
+		s = strings.ReplaceAll(s, value, Placeholder)
+	}
+	return s
+}
+
+// MaskHeader masks the value of a header, the value of a sensitive header is fully masked.
+func (r *Registry) MaskHeader(name string, value string) string {
+	if IsSensitiveHeader(name) {
+		return Placeholder
+	}
+	return r.Mask(value)
+}
+
+// IsSensitiveHeader checks if the header contains credentials.
+func IsSensitiveHeader(name string) bool {
+	return sensitiveHeaders[http.CanonicalHeaderKey(name)]
+}
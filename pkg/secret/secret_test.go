@@ -0,0 +1,98 @@
+package secret_test
+
+import (
+	"testing"
+
+	"github.com/thomaspoignant/api-scenario/pkg/secret"
+	"github.com/thomaspoignant/api-scenario/test"
+)
+
+func TestIsSecretName(t *testing.T) {
+	registry := secret.NewRegistry()
+	registry.AddName("customer_id")
+	test.Ok(t, registry.AddNamePattern("^priv_"))
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"baseUrl", false},
+		{"customer_id", true},
+		{"priv_value", true},
+		{"access_token", true},
+		{"DB_PASSWORD", true},
+		{"apiKey", true},
+		{"client_secret", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test.Equals(t, "wrong result", tt.want, registry.IsSecretName(tt.name))
+		})
+	}
+}
+
+func TestAddVariable(t *testing.T) {
+	registry := secret.NewRegistry()
+	registry.AddName("pin")
+	test.Ok(t, registry.AddNamePattern("^code_"))
+	registry.AddVariable("pin", "1234")
+	registry.AddVariable("code_user", "42")
+	registry.AddVariable("api_token", "true")
+	registry.AddVariable("access_token", "abcdef")
+	registry.AddVariable("baseUrl", "https://test.com")
+
+	got := registry.Mask("pin=1234 user=42 active=true token=abcdef url=https://test.com")
+	test.Equals(t, "wrong masked value", "pin=**** user=**** active=true token=**** url=https://test.com", got)
+}
+
+func TestIsSecret(t *testing.T) {
+	registry := secret.NewRegistry()
+	registry.AddName("pin")
+	test.Ok(t, registry.AddNamePattern("^api_"))
+
+	tests := []struct {
+		name  string
+		value string
+		want  bool
+	}{
+		{"pin", "1", true},
+		{"api_key", "abc12", true},
+		{"access_token", "abc12", false},
+		{"access_token", "abc123", true},
+		{"baseUrl", "https://test.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+"="+tt.value, func(t *testing.T) {
+			test.Equals(t, "wrong result", tt.want, registry.IsSecret(tt.name, tt.value))
+		})
+	}
+}
+
+func TestAddNamePatternInvalid(t *testing.T) {
+	err := secret.NewRegistry().AddNamePattern("(")
+	test.Ko(t, err)
+	test.Equals(t, "wrong error", "invalid secret pattern \"(\": error parsing regexp: missing closing ): `(`", err.Error())
+}
+
+func TestMask(t *testing.T) {
+	registry := secret.NewRegistry()
+	registry.AddValue("abc")
+	registry.AddValue("abc123")
+	registry.AddValue("  ")
+
+	test.Equals(t, "should mask the longest value first", "token=**** and ****", registry.Mask("token=abc123 and abc"))
+	test.Equals(t, "should not mask the blank values", "a  b", registry.Mask("a  b"))
+
+	registry.Reset()
+	test.Equals(t, "should not mask after a reset", "abc", registry.Mask("abc"))
+}
+
+func TestMaskHeader(t *testing.T) {
+	registry := secret.NewRegistry()
+	registry.AddHeader("authorization", "Bearer Token123")
+	registry.AddHeader("Accept", "application/json")
+
+	test.Equals(t, "should mask a sensitive header", secret.Placeholder, registry.MaskHeader("Authorization", "Bearer Token123"))
+	test.Equals(t, "should not mask an other header", "application/json", registry.MaskHeader("Accept", "application/json"))
+	test.Equals(t, "should register the credentials without the scheme", "token is ****", registry.Mask("token is Token123"))
+}
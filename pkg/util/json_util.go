@@ -6,6 +6,8 @@ import (
 	"io"
 	"regexp"
 	"strings"
+
+	"github.com/spyzhov/ajson"
 )
 
 func IsJson(str string) bool {
@@ -32,3 +34,154 @@ func StringToJson(s string) (map[string]interface{}, error) {
 	}
 	return data, nil
 }
+
+// ParseJsonPath parses a JSONPath expression and returns its commands.
+// For compatibility with the previous syntax, an expression without "$" is relative to the root
+// and the indexes could use the dot notation (ex: data[0].id or data.0.id).
+func ParseJsonPath(expression string) ([]string, error) {
+	normalized := strings.TrimSpace(expression)
+	switch {
+	case len(normalized) == 0:
+		normalized = "$"
+	case strings.HasPrefix(normalized, "["):
+		normalized = "$" + normalized
+	case !strings.HasPrefix(normalized, "$"):
+		normalized = "$." + normalized
+	}
+
+	commands, err := ajson.ParseJSONPath(quoteDottedNames(normalized))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSONPath %q: %v", expression, err)
+	}
+
+	// the filters and the scripts are only parsed when they are applied, evaluate them on a null value to check
+	// the syntax, a wrong type is expected for a valid expression.
+	for _, command := range commands[1:] {
+		script := strings.TrimPrefix(command, "?")
+		if !strings.HasPrefix(script, "(") || !strings.HasSuffix(script, ")") {
+			continue
+		}
+		_, err := ajson.Eval(ajson.NullNode(""), script[1:len(script)-1])
+		if jsonErr, ok := err.(ajson.Error); err != nil && (!ok || jsonErr.Type != ajson.WrongType) {
+			return nil, fmt.Errorf("invalid JSONPath %q: %v", expression, err)
+		}
+	}
+	return commands, nil
+}
+
+// quoteDottedNames rewrites the dotted names containing ":" or "," with the bracket notation, these characters are
+// a slice or a union only inside a bracket index (ex: $.a:b is the key "a:b").
+func quoteDottedNames(path string) string {
+	var result strings.Builder
+	depth := 0
+	var quote byte
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case depth > 0 && (c == '\'' || c == '"'):
+			quote = c
+		case c == '[' || c == '(':
+			depth++
+		case c == ']' || c == ')':
+			depth--
+		case c == '.' && depth == 0:
+			end := i + 1
+			for end < len(path) && path[end] != '.' && path[end] != '[' {
+				end++
+			}
+			name := path[i+1 : end]
+			if !strings.ContainsAny(name, ":,") {
+				break
+			}
+			// keep the first dot of a recursive descent
+			if i > 0 && path[i-1] == '.' {
+				result.WriteByte('.')
+			}
+			nameQuote := "'"
+			if strings.Contains(name, "'") {
+				nameQuote = `"`
+			}
+			result.WriteString("[" + nameQuote + name + nameQuote + "]")
+			i = end - 1
+			continue
+		}
+		result.WriteByte(c)
+	}
+	return result.String()
+}
+
+// JsonPathQuery returns every value targeted by the JSONPath expression in the JSON document,
+// an empty document is an empty object.
+func JsonPathQuery(document string, expression string) ([]interface{}, error) {
+	commands, err := ParseJsonPath(expression)
+	if err != nil {
+		return nil, err
+	}
+	return jsonPathQuery(document, expression, commands)
+}
+
+// JsonPathValue returns the value targeted by the JSONPath expression in the JSON document and if it exists.
+// For a singular path the value is returned directly, for the other paths (wildcard, slice, filter, union or
+// recursive descent) it is the list of all the values found.
+func JsonPathValue(document string, expression string) (interface{}, bool, error) {
+	commands, err := ParseJsonPath(expression)
+	if err != nil {
+		return nil, false, err
+	}
+	values, err := jsonPathQuery(document, expression, commands)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !isSingularJsonPath(commands) {
+		if values == nil {
+			values = []interface{}{}
+		}
+		return values, true, nil
+	}
+	if len(values) == 0 {
+		return nil, false, nil
+	}
+	return values[0], true, nil
+}
+
+func jsonPathQuery(document string, expression string, commands []string) ([]interface{}, error) {
+	if len(strings.TrimSpace(document)) == 0 {
+		document = "{}"
+	}
+	root, err := ajson.Unmarshal([]byte(document))
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := ajson.ApplyJSONPath(root, commands)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSONPath %q: %v", expression, err)
+	}
+	var values []interface{}
+	for _, node := range nodes {
+		value, err := node.Unpack()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// isSingularJsonPath returns true if the commands could target at most one value (only names and indexes).
+func isSingularJsonPath(commands []string) bool {
+	for _, command := range commands[1:] {
+		if strings.HasPrefix(command, "'") || strings.HasPrefix(command, `"`) || strings.HasPrefix(command, "(") {
+			continue
+		}
+		if command == "*" || command == ".." || strings.HasPrefix(command, "?(") || strings.ContainsAny(command, ":,") {
+			return false
+		}
+	}
+	return true
+}
@@ -76,3 +76,55 @@ func TestStringToJsonEmpty(t *testing.T) {
 		t.Errorf("want %v, got %v", want, got)
 	}
 }
+
+func TestJsonPathValueLegacyDotIndex(t *testing.T) {
+	got, found, err := util.JsonPathValue(`{"data":[{"id":1},{"id":2}]}`, "data.1.id")
+	test.Ok(t, err)
+	test.Equals(t, "should find the value", true, found)
+	test.Equals(t, "wrong value", float64(2), got)
+}
+
+func TestJsonPathValueLegacyKeyWithColon(t *testing.T) {
+	document := `{"a:b":1,"data":{"c:d":2},"list":[1,2,3]}`
+	tests := []struct {
+		expression string
+		want       interface{}
+	}{
+		{"a:b", float64(1)},
+		{"$.data.c:d", float64(2)},
+		{"$..c:d", []interface{}{float64(2)}},
+		{"$['a:b']", float64(1)},
+		{"list[1:]", []interface{}{float64(2), float64(3)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, found, err := util.JsonPathValue(document, tt.expression)
+			test.Ok(t, err)
+			test.Equals(t, "should find the value", true, found)
+			test.Equals(t, "wrong value", tt.want, got)
+		})
+	}
+}
+
+func TestJsonPathValueList(t *testing.T) {
+	got, found, err := util.JsonPathValue(`{"data":[{"id":1,"type":"admin"},{"id":2,"type":"user"}]}`, "$.data[?(@.type=='admin')].id")
+	test.Ok(t, err)
+	test.Equals(t, "should find the value", true, found)
+	test.Equals(t, "wrong value", []interface{}{float64(1)}, got)
+}
+
+func TestJsonPathValueNotFound(t *testing.T) {
+	_, found, err := util.JsonPathValue(`{"data":[]}`, "$.data[0].id")
+	test.Ok(t, err)
+	test.Equals(t, "should not find the value", false, found)
+}
+
+func TestParseJsonPathInvalidFilter(t *testing.T) {
+	_, err := util.ParseJsonPath("$.data[?(@.id == )]")
+	test.Ko(t, err)
+}
+
+func TestParseJsonPathValidFilter(t *testing.T) {
+	_, err := util.ParseJsonPath("$.data[?(@.name =~ '^a' && length(@.tags) > 1)]")
+	test.Ok(t, err)
+}
@@ -29,3 +29,23 @@ func TestIsNumeric(t *testing.T) {
 		})
 	}
 }
+
+func TestIsText(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		want    bool
+	}{
+		{"Empty content", []byte{}, true},
+		{"Text", []byte(`{"name": "{{name}}", "city": "Zürich"}`), true},
+		{"Invalid UTF-8", []byte{0x89, 'P', 'N', 'G'}, false},
+		{"NUL character", []byte{'a', 0, 'b'}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := util.IsText(tt.content); got != tt.want {
+				t.Errorf("IsText() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,7 +1,13 @@
 package test
 
 import (
+	"crypto/tls"
 	"github.com/sendgrid/rest"
+	"github.com/thomaspoignant/api-scenario/pkg/model"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
 )
 
 type ClientMock struct {
@@ -13,14 +19,21 @@ var jsonBody = `{
 				"param2":123
 				}`
 
-var xmlBody =`<root>
+var xmlBody = `<root>
 				<hello>world</hello>
 				<param1>true</param1>
 				<param2>123</param2>
 		   	  </root>`
 
-func (c *ClientMock) Send(request rest.Request) (*rest.Response, error) {
-	testNumber:=request.QueryParams["testNumber"]
+var htmlBody = `<html><body>
+				<form action="/login" method="post">
+					<input type="hidden" name="csrf_token" value="a1b2c3">
+				</form>
+			  </body></html>`
+
+func (c *ClientMock) Send(request rest.Request, timeout time.Duration, jar http.CookieJar,
+	tlsConfig *model.TLSConfig) (*rest.Response, *tls.ConnectionState, error) {
+	testNumber := request.QueryParams["testNumber"]
 
 	response := &rest.Response{
 		StatusCode: 200,
@@ -31,11 +44,29 @@ func (c *ClientMock) Send(request rest.Request) (*rest.Response, error) {
 
 	if testNumber == "1" {
 		response.Body = jsonBody
-		return response, nil
+		return response, nil, nil
 	} else if testNumber == "2" {
 		response.Body = xmlBody
-		return response, nil
+		return response, nil, nil
+	} else if testNumber == "3" {
+		response.Body = htmlBody
+		return response, nil, nil
+	} else if testNumber == "4" {
+		// the body contains the cookies sent, and the response sets a session cookie in the jar as an HTTP client
+		u, _ := url.Parse(request.BaseURL)
+		var cookies []string
+		if jar != nil {
+			for _, cookie := range jar.Cookies(u) {
+				cookies = append(cookies, cookie.String())
+			}
+		}
+		response.Body = strings.Join(cookies, "; ")
+		response.Headers["Set-Cookie"] = []string{"session=abc; Path=/; Domain=test.com; Max-Age=3600; HttpOnly; Secure"}
+		if jar != nil {
+			jar.SetCookies(u, (&http.Response{Header: response.Headers}).Cookies())
+		}
+		return response, nil, nil
 	}
 
-	return &rest.Response{}, nil
+	return &rest.Response{}, nil, nil
 }